@@ -7,6 +7,7 @@ package cliprompt
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -17,6 +18,12 @@ import (
 
 type Prompt struct{}
 
+// Interactive reports if stdin is a terminal, so the user can be asked.
+func Interactive() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // Ask the user a question based on the CLI.
 // TODO (DT): Currently can't handle fetching empty responses do to cancel method.
 func (p *Prompt) Ask(q *prompt.Question) (prompt.Response, error) {
@@ -33,7 +40,7 @@ func (p *Prompt) Ask(q *prompt.Question) (prompt.Response, error) {
 	default:
 		panic("Unknown question type")
 	case prompt.TypeSelectMultiple:
-		return prompt.RespCancel, fmt.Errorf("Selecting multiple isn't currently supported")
+		return getMultiple(term, q)
 	case prompt.TypeSelectOne:
 		return getSingle(term, q)
 	}
@@ -152,3 +159,97 @@ func setOption(term *cp.Terminal, q *prompt.Question) (prompt.Response, error) {
 		return prompt.RespAnswer, nil
 	}
 }
+
+func getMultiple(term *cp.Terminal, q *prompt.Question) (prompt.Response, error) {
+	var internalMessage = ""
+	for {
+		// Write out messages
+		if len(internalMessage) > 0 {
+			fmt.Fprintf(term.Out, "%s\n\n", internalMessage)
+		}
+		if len(q.Prompt) > 0 {
+			fmt.Fprintf(term.Out, "%s\n", q.Prompt)
+		}
+		for index, opt := range q.Options {
+			mark := " "
+			if opt.Chosen {
+				mark = "x"
+			}
+			fmt.Fprintf(term.Out, " [%s] (%d) %s\n", mark, index+1, opt.Prompt())
+			if len(opt.Validation()) > 0 {
+				fmt.Fprintf(term.Out, "  ** %s\n", opt.Validation())
+			}
+		}
+		fmt.Fprintf(term.Out, "Enter numbers or ranges to toggle, \"all\" or \"none\". Press enter to accept, \"q\" to cancel.\n")
+		// Reset message.
+		internalMessage = ""
+		ln, err := term.Basic(" # ", false)
+		if err != nil {
+			return prompt.RespCancel, err
+		}
+		switch ln = strings.TrimSpace(ln); ln {
+		case "":
+			return prompt.RespAnswer, nil
+		case "q":
+			return prompt.RespCancel, nil
+		case "all", "none":
+			for i := range q.Options {
+				opt := &q.Options[i]
+				if opt.Other() {
+					continue
+				}
+				opt.Chosen = ln == "all"
+			}
+			continue
+		}
+		choices, err := parseChoices(ln, len(q.Options))
+		if err != nil {
+			internalMessage = err.Error()
+			continue
+		}
+		for _, index := range choices {
+			opt := &q.Options[index]
+			opt.Chosen = !opt.Chosen
+			if !opt.Chosen || !opt.Other() {
+				continue
+			}
+			res, err := setOther(term, q, opt)
+			if err != nil {
+				return prompt.RespCancel, err
+			}
+			if res == prompt.RespCancel {
+				opt.Chosen = false
+			}
+		}
+	}
+}
+
+// parseChoices parses a list of option numbers and ranges, such as "1,3 5-7",
+// into option indexes.
+func parseChoices(ln string, count int) ([]int, error) {
+	fields := strings.FieldsFunc(ln, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	var choices []int
+	for _, field := range fields {
+		from, to := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			from, to = field[:i], field[i+1:]
+		}
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("Not a valid number %q.", from)
+		}
+		last, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("Not a valid number %q.", to)
+		}
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("Not a valid choice %q.", field)
+		}
+		for n := first; n <= last; n++ {
+			choices = append(choices, n-1)
+		}
+	}
+	return choices, nil
+}
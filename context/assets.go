@@ -0,0 +1,456 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+	"github.com/pkg/errors"
+)
+
+// Kinds of package file references.
+const (
+	RefInclude = "include" // A cgo `#include "file"`.
+	RefCgoDir  = "cgo dir" // A cgo "-I" or "-L" folder.
+	RefEmbed   = "embed"   // A "//go:embed" pattern.
+	RefAsset   = "asset"   // A pattern of the vendor file package "assets" field.
+)
+
+// UnresolvedRef is a cgo, go:embed or declared asset reference of a copied
+// package that does not match any file within the source tree.
+type UnresolvedRef struct {
+	Package string // Import path of the package.
+	File    string // File with the reference, relative to the package folder.
+	Kind    string
+	Ref     string
+}
+
+func (u UnresolvedRef) String() string {
+	if len(u.File) == 0 {
+		return fmt.Sprintf("%s: %s %q", u.Package, u.Kind, u.Ref)
+	}
+	return fmt.Sprintf("%s/%s: %s %q", u.Package, u.File, u.Kind, u.Ref)
+}
+
+// packageRef is a reference to files outside of those copied with the
+// package. All paths are slash separated and relative to the package
+// folder, and may start with "..". A RefInclude ref is as written and
+// relative to each of the includeDirs.
+type packageRef struct {
+	file string
+	kind string
+	ref  string
+
+	includeDirs []string // Folders searched for RefInclude, the file folder first.
+	all         bool     // RefEmbed "all:" pattern, include "." and "_" files.
+}
+
+// fileRefs returns the cgo and go:embed references of the Go file name in
+// the package folder rel.
+func fileRefs(srcDir, rel, name string) ([]packageRef, error) {
+	b, err := ioutil.ReadFile(filepath.Join(srcDir, name))
+	if err != nil {
+		return nil, err
+	}
+	file := path.Join(rel, name)
+	var refs []packageRef
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(nil, len(b)+1)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "//go:embed") {
+			continue
+		}
+		rest := strings.TrimPrefix(line, "//go:embed")
+		if len(rest) == 0 || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		patterns, err := parseEmbedPatterns(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		for _, p := range patterns {
+			r := packageRef{file: file, kind: RefEmbed}
+			if strings.HasPrefix(p, "all:") {
+				r.all = true
+				p = p[4:]
+			}
+			r.ref = path.Join(rel, p)
+			refs = append(refs, r)
+		}
+	}
+
+	f, _ := parser.ParseFile(token.NewFileSet(), name, b, parser.ImportsOnly|parser.ParseComments)
+	if f == nil {
+		return refs, nil
+	}
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range d.Specs {
+			is := spec.(*ast.ImportSpec)
+			if is.Path.Value != `"C"` {
+				continue
+			}
+			doc := is.Doc
+			if doc == nil && len(d.Specs) == 1 {
+				doc = d.Doc
+			}
+			if doc != nil {
+				refs = append(refs, cgoRefs(rel, file, doc.Text())...)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// parseEmbedPatterns splits the patterns of a go:embed line, which may be
+// Go string literals.
+func parseEmbedPatterns(s string) ([]string, error) {
+	var list []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if len(s) == 0 {
+			return list, nil
+		}
+		switch s[0] {
+		case '"', '`':
+			// Find the closing quote, skipping escaped characters.
+			end := -1
+			for i := 1; i < len(s); i++ {
+				if s[0] == '"' && s[i] == '\\' {
+					i++
+					continue
+				}
+				if s[i] == s[0] {
+					end = i
+					break
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", s)
+			}
+			p, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", s)
+			}
+			list = append(list, p)
+			s = s[end+1:]
+		default:
+			i := strings.IndexAny(s, " \t")
+			if i < 0 {
+				i = len(s)
+			}
+			list = append(list, s[:i])
+			s = s[i:]
+		}
+	}
+}
+
+// cgoRefs returns the quoted includes and the "-I" and "-L" folders of a
+// cgo preamble.
+func cgoRefs(rel, file, preamble string) []packageRef {
+	var dirs, includes []string
+	for _, line := range strings.Split(preamble, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(line[1:])
+		switch {
+		case strings.HasPrefix(line, "cgo ") || strings.HasPrefix(line, "cgo\t"):
+			i := strings.IndexByte(line, ':')
+			if i < 0 {
+				continue
+			}
+			flags := strings.Fields(line[i+1:])
+			for j := 0; j < len(flags); j++ {
+				var dir string
+				switch flag := flags[j]; {
+				case (flag == "-I" || flag == "-L") && j+1 < len(flags):
+					j++
+					dir = flags[j]
+				case strings.HasPrefix(flag, "-I"), strings.HasPrefix(flag, "-L"):
+					dir = flag[2:]
+				default:
+					continue
+				}
+				dir = strings.Trim(dir, `"'`)
+				dir = strings.Replace(dir, "${SRCDIR}", ".", -1)
+				if len(dir) == 0 || filepath.IsAbs(dir) || strings.HasPrefix(dir, "$") {
+					continue
+				}
+				dirs = append(dirs, path.Join(rel, filepath.ToSlash(dir)))
+			}
+		case strings.HasPrefix(line, "include"):
+			line = strings.TrimSpace(line[len("include"):])
+			if !strings.HasPrefix(line, `"`) {
+				continue
+			}
+			end := strings.IndexByte(line[1:], '"')
+			if end <= 0 {
+				continue
+			}
+			includes = append(includes, line[1:end+1])
+		}
+	}
+	var refs []packageRef
+	for _, dir := range dirs {
+		refs = append(refs, packageRef{file: file, kind: RefCgoDir, ref: dir})
+	}
+	for _, inc := range includes {
+		refs = append(refs, packageRef{
+			file:        file,
+			kind:        RefInclude,
+			ref:         inc,
+			includeDirs: append([]string{rel}, dirs...),
+		})
+	}
+	return refs
+}
+
+// assetRefs returns the declared assets of the vendor file package.
+func assetRefs(vp *vendorfile.Package) []packageRef {
+	if vp == nil {
+		return nil
+	}
+	var refs []packageRef
+	for _, p := range strings.Fields(vp.Assets) {
+		refs = append(refs, packageRef{kind: RefAsset, ref: path.Clean(p)})
+	}
+	return refs
+}
+
+// resolveRefs returns the files the refs resolve to that were not already
+// copied, sorted. Files must be within lookRoot, the root of the package
+// import path, and not leave the vendor folder.
+func resolveRefs(srcPath, lookRoot, pkgPath string, refs []packageRef, copied map[string]bool) (files []string, unresolved []UnresolvedRef) {
+	found := make(map[string]bool)
+	within := func(rel string) bool {
+		if strings.HasPrefix(path.Join(strings.Trim(pkgPath, "/"), rel), "..") {
+			return false
+		}
+		return pathos.FileHasPrefix(filepath.Join(srcPath, filepath.FromSlash(rel)), lookRoot)
+	}
+	add := func(rel string) {
+		if !copied[rel] {
+			found[rel] = true
+		}
+	}
+	// addDir adds the files in the folder rel.
+	addDir := func(rel string, all bool) bool {
+		dir := filepath.Join(srcPath, filepath.FromSlash(rel))
+		fi, err := os.Stat(dir)
+		if err != nil || !fi.IsDir() {
+			return false
+		}
+		filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := info.Name()
+			if p != dir && !all && (name[0] == '.' || name[0] == '_') {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			sub, err := filepath.Rel(dir, p)
+			if err == nil {
+				add(path.Join(rel, filepath.ToSlash(sub)))
+			}
+			return nil
+		})
+		return true
+	}
+	isFile := func(rel string) bool {
+		fi, err := os.Stat(filepath.Join(srcPath, filepath.FromSlash(rel)))
+		return err == nil && fi.Mode().IsRegular()
+	}
+
+	for _, r := range refs {
+		resolved := false
+		switch r.kind {
+		case RefInclude:
+			for _, dir := range r.includeDirs {
+				c := path.Join(dir, r.ref)
+				if within(c) && isFile(c) {
+					add(c)
+					resolved = true
+					break
+				}
+			}
+		case RefCgoDir:
+			resolved = within(r.ref) && addDir(r.ref, false)
+		case RefEmbed, RefAsset:
+			if !within(r.ref) {
+				break
+			}
+			matches, _ := filepath.Glob(filepath.Join(srcPath, filepath.FromSlash(r.ref)))
+			for _, m := range matches {
+				rel, err := filepath.Rel(srcPath, m)
+				if err != nil {
+					continue
+				}
+				rel = filepath.ToSlash(rel)
+				if !within(rel) {
+					continue
+				}
+				if isFile(rel) {
+					add(rel)
+					resolved = true
+				} else if addDir(rel, r.all) {
+					resolved = true
+				}
+			}
+		}
+		if !resolved {
+			unresolved = append(unresolved, UnresolvedRef{Package: pkgPath, File: r.file, Kind: r.kind, Ref: r.ref})
+		}
+	}
+	for rel := range found {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	return files, unresolved
+}
+
+// copyExtraFiles copies the files, relative to the package folder, that
+// are referenced by the package but not copied with it.
+func copyExtraFiles(destPath, srcPath, pkgPath string, files []string, h io.Writer) error {
+	for _, rel := range files {
+		dest := filepath.Join(destPath, filepath.FromSlash(rel))
+		err := os.MkdirAll(filepath.Dir(dest), 0777)
+		if err != nil {
+			return err
+		}
+		writeExtraName(h, pkgPath, rel)
+		err = copyFile(dest, filepath.Join(srcPath, filepath.FromSlash(rel)), h)
+		if fh, ok := h.(fileHasher); ok {
+			fh.endFile()
+		}
+		if err != nil {
+			return errors.Wrapf(err, "copy extra file %q", rel)
+		}
+	}
+	return nil
+}
+
+// writeExtraName starts the hash of an extra file.
+func writeExtraName(h io.Writer, pkgPath, rel string) {
+	if h == nil {
+		return
+	}
+	h.Write([]byte(rel))
+	if fh, ok := h.(fileHasher); ok {
+		fh.beginFile(path.Join(strings.Trim(pkgPath, "/"), rel))
+	}
+}
+
+// hashExtraFiles writes the extra files of the vendor package to h the
+// same way copyExtraFiles does.
+func hashExtraFiles(root string, vp *vendorfile.Package, h io.Writer) error {
+	pkgDir := filepath.Join(root, pathos.SlashToFilepath(vp.Path))
+	for _, rel := range strings.Fields(vp.ExtraFiles) {
+		f, err := os.Open(filepath.Join(pkgDir, filepath.FromSlash(rel)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Missing files are reported by the checksum.
+				continue
+			}
+			return err
+		}
+		writeExtraName(h, vp.Path, rel)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if fh, ok := h.(fileHasher); ok {
+			fh.endFile()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeExtraFiles removes the extra files of the vendor package pkgPath
+// that are not in keep. Extra files may be outside of the package folder,
+// so they are not removed with it. Files still used by a vendor package
+// are left in place. Folders left empty are removed up to the
+// vendor folder.
+func (ctx *Context) removeExtraFiles(pkgPath string, files, keep []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	root := ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder))
+	used := make(map[string]bool, len(keep))
+	for _, rel := range keep {
+		used[path.Join(pkgPath, rel)] = true
+	}
+	for _, rel := range files {
+		name := path.Join(pkgPath, rel)
+		if used[name] || name == ".." || strings.HasPrefix(name, "../") || ctx.extraFileUsed(name) {
+			continue
+		}
+		fp := filepath.Join(root, filepath.FromSlash(name))
+		err := os.Remove(fp)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove extra file %q", rel)
+		}
+		removeEmptyFolders(filepath.Dir(fp), root)
+	}
+	return nil
+}
+
+// removedPackage returns the vendor file package pkgPath that is being
+// removed, or nil if there is none.
+func (ctx *Context) removedPackage(pkgPath string) *vendorfile.Package {
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove && vp.Path == pkgPath {
+			return vp
+		}
+	}
+	return nil
+}
+
+// extraFileUsed reports if the file name, relative to the vendor folder,
+// is in the folder or the extra files of a vendor package.
+func (ctx *Context) extraFileUsed(name string) bool {
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if path.Dir(name) == vp.Path || (vp.Tree && strings.HasPrefix(name, vp.Path+"/")) {
+			return true
+		}
+		for _, rel := range strings.Fields(vp.ExtraFiles) {
+			if path.Join(vp.Path, rel) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestParseEmbedPatterns(t *testing.T) {
+	list := []struct {
+		Line string
+		Want string
+	}{
+		{" a.txt  b/*.html", "a.txt|b/*.html"},
+		{"\t\"with space.txt\" `raw.txt` all:dir", "with space.txt|raw.txt|all:dir"},
+		{` "esc\"aped"`, `esc"aped`},
+	}
+	for _, item := range list {
+		got, err := parseEmbedPatterns(item.Line)
+		if err != nil {
+			t.Errorf("%q: %v", item.Line, err)
+			continue
+		}
+		if strings.Join(got, "|") != item.Want {
+			t.Errorf("%q: got %q", item.Line, got)
+		}
+	}
+	if _, err := parseEmbedPatterns(` "open`); err == nil {
+		t.Error("expected error for unterminated string")
+	}
+}
+
+func TestCgoRefs(t *testing.T) {
+	preamble := `#cgo CFLAGS: -I${SRCDIR}/../include -I /usr/include -DX=1
+#cgo linux LDFLAGS: -L${SRCDIR}/lib -lfoo
+#include <stdio.h>
+# include "x.h"
+int f() { return 1; }
+`
+	refs := cgoRefs("sub", "sub/a.go", preamble)
+	var got []string
+	for _, r := range refs {
+		got = append(got, r.kind+":"+r.ref+":"+strings.Join(r.includeDirs, ","))
+	}
+	want := "cgo dir:include:|cgo dir:sub/lib:|include:x.h:sub,include,sub/lib"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCopyAssets(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("b.go", "strings"),
+	)
+	src := g.Path("co2/pk1")
+	writeFile(t, filepath.Join(src, "a.go"), `package pk1
+
+// #cgo CFLAGS: -I${SRCDIR}/../include
+// #include "../common/x.h"
+// #include "a.h"
+// #include "missing.h"
+import "C"
+
+import "embed"
+
+//go:embed static/*.html tmpl
+var content embed.FS
+
+//go:embed none/*.txt
+var none embed.FS
+`)
+	put := func(name, content string) {
+		g.Check(os.MkdirAll(filepath.Dir(name), 0777))
+		writeFile(t, name, content)
+	}
+	put(filepath.Join(src, "static", "index.html"), "<html>")
+	put(filepath.Join(src, "static", "skip.css"), "body{}")
+	put(filepath.Join(src, "tmpl", "a.tmpl"), "{{.}}")
+	put(filepath.Join(src, "tmpl", "_hidden"), "hidden")
+	put(filepath.Join(src, "data", "x.json"), "{}")
+	put(filepath.Join(g.Path("co2"), "include", "a.h"), "int a;")
+	put(filepath.Join(g.Path("co2"), "common", "x.h"), "int x;")
+
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	vp := c.VendorFilePackagePath("co2/pk1")
+	vp.Assets = "data/*.json"
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Update))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	tree(g, "copied", `
+/pk1/a.go
+/vendor/co2/common/x.h
+/vendor/co2/include/a.h
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/b.go
+/vendor/co2/pk1/data/x.json
+/vendor/co2/pk1/static/index.html
+/vendor/co2/pk1/tmpl/a.tmpl
+/vendor/vendor.json
+`)
+	vp = c.VendorFilePackagePath("co2/pk1")
+	if want := "../common/x.h ../include/a.h data/x.json static/index.html tmpl/a.tmpl"; vp.ExtraFiles != want {
+		t.Errorf("got extra files %q", vp.ExtraFiles)
+	}
+	var unresolved []string
+	for _, u := range c.Unresolved {
+		unresolved = append(unresolved, u.String())
+	}
+	if want := `co2/pk1/a.go: embed "none/*.txt"|co2/pk1/a.go: include "missing.h"`; strings.Join(unresolved, "|") != want {
+		t.Errorf("got unresolved %q", unresolved)
+	}
+	verifyChecksum(g, ctx(g), "copied")
+
+	// Extra files are part of the checksum.
+	writeFile(t, filepath.Join(g.Current(), "vendor", "co2", "include", "a.h"), "int b;")
+	list, err := ctx(g).VerifyVendor()
+	g.Check(err)
+	if len(list) != 1 {
+		t.Fatalf("expected modified extra file to fail the checksum")
+	}
+	g.Check(os.Remove(filepath.Join(g.Current(), "vendor", "co2", "include", "a.h")))
+	list, err = ctx(g).VerifyVendor()
+	g.Check(err)
+	if len(list) != 1 {
+		t.Fatalf("expected missing extra file to fail the checksum")
+	}
+
+	// Extra files no longer referenced are removed on update.
+	writeFile(t, filepath.Join(src, "a.go"), `package pk1
+
+// #include "a.h"
+import "C"
+`)
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Update))
+	g.Check(c.Alter())
+	tree(g, "dropped", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/b.go
+/vendor/co2/pk1/data/x.json
+/vendor/vendor.json
+`)
+
+	// Extra files are removed with the package.
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Remove))
+	g.Check(c.Alter())
+	tree(g, "removed", `
+/pk1/a.go
+/vendor/vendor.json
+`)
+}
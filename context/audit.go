@@ -0,0 +1,401 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kardianos/govendor/vendorfile"
+	"github.com/pkg/errors"
+)
+
+// Advisory is a vulnerability advisory in the OSV format. Only the fields
+// needed to match vendor packages are read.
+type Advisory struct {
+	ID        string             `json:"id"`
+	Aliases   []string           `json:"aliases"`
+	Summary   string             `json:"summary"`
+	Withdrawn string             `json:"withdrawn"`
+	Affected  []AdvisoryAffected `json:"affected"`
+}
+
+// AdvisoryAffected lists the affected versions of a package.
+type AdvisoryAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []AdvisoryRange `json:"ranges"`
+	Versions []string        `json:"versions"`
+}
+
+// AdvisoryRange is a range of affected versions or commits.
+type AdvisoryRange struct {
+	Type   string          `json:"type"` // "SEMVER", "ECOSYSTEM" or "GIT".
+	Repo   string          `json:"repo"` // Repo URL of a "GIT" range.
+	Events []AdvisoryEvent `json:"events"`
+}
+
+// AdvisoryEvent starts or ends a range. Only one field is set.
+type AdvisoryEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+// AuditFinding is a vendor package affected by an advisory.
+type AuditFinding struct {
+	Package  *vendorfile.Package
+	Advisory *Advisory
+	Fixed    string // First fixed version or commit, empty if there is no fix.
+}
+
+// ReadAdvisories reads the OSV advisories from each JSON file in dir and
+// its sub-folders. A file may contain a single advisory or a list.
+func ReadAdvisories(dir string) ([]*Advisory, error) {
+	var list []*Advisory
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		b = bytes.TrimSpace(b)
+		if len(b) > 0 && b[0] == '[' {
+			var fileList []*Advisory
+			err = json.Unmarshal(b, &fileList)
+			list = append(list, fileList...)
+		} else {
+			adv := &Advisory{}
+			err = json.Unmarshal(b, adv)
+			list = append(list, adv)
+		}
+		return errors.Wrapf(err, "read advisory %q", p)
+	})
+	return list, err
+}
+
+// Audit matches each vendor file package against the advisories. Version
+// ranges are matched to the package exact version. Commit ranges are
+// matched to the package revision with the history of the cached repo.
+func (ctx *Context) Audit(advisories []*Advisory) ([]*AuditFinding, error) {
+	var found []*AuditFinding
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Path) == 0 {
+			continue
+		}
+		for _, adv := range advisories {
+			if len(adv.Withdrawn) > 0 {
+				continue
+			}
+			affected, fixed, err := ctx.auditPackage(vp, adv)
+			if err != nil {
+				return nil, errors.Wrapf(err, "audit %q with %s", vp.Path, adv.ID)
+			}
+			if affected {
+				found = append(found, &AuditFinding{
+					Package:  vp,
+					Advisory: adv,
+					Fixed:    fixed,
+				})
+			}
+		}
+	}
+	return found, nil
+}
+
+func (ctx *Context) auditPackage(vp *vendorfile.Package, adv *Advisory) (affected bool, fixed string, err error) {
+	version := vp.VersionExact
+	if len(version) == 0 && len(vp.Version) > 0 && vp.Version[0] == '=' {
+		version = vp.Version[1:]
+	}
+	for _, aff := range adv.Affected {
+		switch aff.Package.Ecosystem {
+		case "", "Go":
+		default:
+			continue
+		}
+		nameMatch := len(aff.Package.Name) > 0 && pathMatchesPrefix(vp.Path, aff.Package.Name)
+		if nameMatch && len(version) > 0 {
+			for _, v := range aff.Versions {
+				if compareSemver(v, version) == 0 {
+					return true, "", nil
+				}
+			}
+		}
+		for _, r := range aff.Ranges {
+			switch r.Type {
+			case "SEMVER", "ECOSYSTEM":
+				if !nameMatch || len(version) == 0 {
+					continue
+				}
+				affected, fixed = versionAffected(version, r.Events)
+			case "GIT":
+				if !nameMatch && !pathMatchesPrefix(vp.Path, repoImportPath(r.Repo)) {
+					continue
+				}
+				if len(vp.Revision) == 0 {
+					continue
+				}
+				affected, fixed, err = ctx.commitAffected(vp, r.Events)
+				if err != nil {
+					return false, "", err
+				}
+			}
+			if affected {
+				return true, fixed, nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// pathMatchesPrefix reports if the import path is the prefix path or
+// within it.
+func pathMatchesPrefix(importPath, prefix string) bool {
+	if len(prefix) == 0 {
+		return false
+	}
+	return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
+
+// repoImportPath returns the import path of a repo URL, such as
+// "github.com/user/repo" for "https://github.com/user/repo.git".
+func repoImportPath(repo string) string {
+	if i := strings.Index(repo, "://"); i >= 0 {
+		repo = repo[i+3:]
+	} else if i := strings.Index(repo, ":"); i >= 0 {
+		// "git@github.com:user/repo" form.
+		repo = repo[:i] + "/" + repo[i+1:]
+	}
+	if i := strings.LastIndex(repo, "@"); i >= 0 && i < strings.Index(repo, "/") {
+		repo = repo[i+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+}
+
+// versionAffected reports if version is within the range events. If it is,
+// the first fixed version after it is returned.
+func versionAffected(version string, events []AdvisoryEvent) (affected bool, fixed string) {
+	type point struct {
+		version string
+		kind    byte // 'i'ntroduced, 'f'ixed, 'l'ast affected.
+	}
+	var points []point
+	for _, e := range events {
+		switch {
+		case len(e.Introduced) > 0:
+			points = append(points, point{e.Introduced, 'i'})
+		case len(e.Fixed) > 0:
+			points = append(points, point{e.Fixed, 'f'})
+		case len(e.LastAffected) > 0:
+			points = append(points, point{e.LastAffected, 'l'})
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return compareSemver(points[i].version, points[j].version) < 0
+	})
+	for _, p := range points {
+		c := compareSemver(version, p.version)
+		switch p.kind {
+		case 'i':
+			if p.version == "0" || c >= 0 {
+				affected = true
+			}
+		case 'f':
+			if c >= 0 {
+				affected = false
+			} else if affected && len(fixed) == 0 {
+				fixed = p.version
+			}
+		case 'l':
+			if c > 0 {
+				affected = false
+			}
+		}
+	}
+	if !affected {
+		fixed = ""
+	}
+	return affected, fixed
+}
+
+// commitAffected reports if the package revision is within the commit range
+// events. If it is, the fixing commit is returned.
+func (ctx *Context) commitAffected(vp *vendorfile.Package, events []AdvisoryEvent) (affected bool, fixed string, err error) {
+	var vcsCmd *VCSCmd
+	var repoDir string
+	// isAncestor reports if commit a is an ancestor of or the same as commit b.
+	isAncestor := func(a, b string) (bool, error) {
+		if strings.HasPrefix(a, b) || strings.HasPrefix(b, a) {
+			return true, nil
+		}
+		if vcsCmd == nil {
+			cacheRoot := ctx.CacheRoot()
+			err := os.MkdirAll(cacheRoot, 0700)
+			if err != nil {
+				return false, err
+			}
+			vcsCmd, repoDir, err = ctx.openCacheRepo(cacheRoot, vp.PathOrigin(), vp.Revision)
+			if err != nil {
+				return false, err
+			}
+			err = ctx.retry("deepen "+repoDir, func() error {
+				return vcsCmd.Deepen(repoDir)
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+		return vcsCmd.IsAncestor(repoDir, a, b)
+	}
+
+	// Like versionAffected, the latest event at or before the revision
+	// decides: an introduced commit after a fixed commit affects it again.
+	rev := vp.Revision
+	var latest *AdvisoryEvent
+	var latestCommit string
+	for i := range events {
+		e := &events[i]
+		commit := e.Introduced
+		if len(commit) == 0 {
+			commit = e.Fixed
+		}
+		if len(commit) == 0 {
+			commit = e.LastAffected
+		}
+		if len(commit) == 0 {
+			continue
+		}
+		if commit != "0" {
+			yes, err := isAncestor(commit, rev)
+			if err != nil {
+				return false, "", err
+			}
+			if !yes {
+				continue
+			}
+		}
+		if latest != nil && latestCommit != "0" {
+			if commit == "0" {
+				continue
+			}
+			yes, err := isAncestor(latestCommit, commit)
+			if err != nil {
+				return false, "", err
+			}
+			if !yes {
+				continue
+			}
+		}
+		latest, latestCommit = e, commit
+	}
+	switch {
+	case latest == nil, len(latest.Fixed) > 0:
+		return false, "", nil
+	case len(latest.LastAffected) > 0:
+		if !strings.HasPrefix(rev, latestCommit) && !strings.HasPrefix(latestCommit, rev) {
+			return false, "", nil
+		}
+		return true, "", nil
+	}
+	// The fixing commit is the first fixed commit after the introduced one.
+	for _, e := range events {
+		if len(e.Fixed) == 0 {
+			continue
+		}
+		yes := true
+		if latestCommit != "0" {
+			var err error
+			yes, err = isAncestor(latestCommit, e.Fixed)
+			if err != nil {
+				return false, "", err
+			}
+		}
+		if yes {
+			return true, e.Fixed, nil
+		}
+	}
+	return true, "", nil
+}
+
+// compareSemver compares two semantic versions, with or without a "v"
+// prefix. Missing minor and patch numbers are zero. Build metadata is
+// ignored. It returns -1, 0 or 1.
+func compareSemver(a, b string) int {
+	parse := func(v string) (nums [3]int64, pre []string) {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexByte(v, '+'); i >= 0 {
+			v = v[:i]
+		}
+		if i := strings.IndexByte(v, '-'); i >= 0 {
+			pre = strings.Split(v[i+1:], ".")
+			v = v[:i]
+		}
+		for i, part := range strings.SplitN(v, ".", 3) {
+			nums[i], _ = strconv.ParseInt(part, 10, 64)
+		}
+		return nums, pre
+	}
+	an, ap := parse(a)
+	bn, bp := parse(b)
+	for i := range an {
+		if an[i] != bn[i] {
+			return cmpInt(an[i], bn[i])
+		}
+	}
+	// A version without a pre-release is greater than one with.
+	switch {
+	case len(ap) == 0 && len(bp) == 0:
+		return 0
+	case len(ap) == 0:
+		return 1
+	case len(bp) == 0:
+		return -1
+	}
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] == bp[i] {
+			continue
+		}
+		ai, aErr := strconv.ParseInt(ap[i], 10, 64)
+		bi, bErr := strconv.ParseInt(bp[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			return cmpInt(ai, bi)
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		case ap[i] < bp[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return cmpInt(int64(len(ap)), int64(len(bp)))
+}
+
+func cmpInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
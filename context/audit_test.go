@@ -0,0 +1,185 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestCompareSemver(t *testing.T) {
+	list := []struct {
+		A, B string
+		Want int
+	}{
+		{"v1.0.0", "1.0.0", 0},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"v2.0.0", "v1.9.9", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"v1.0.0-alpha", "v1.0.0-1", 1},
+		{"v1.0.0+build", "v1.0.0", 0},
+	}
+	for _, item := range list {
+		if got := compareSemver(item.A, item.B); got != item.Want {
+			t.Errorf("compareSemver(%q, %q) = %d, want %d", item.A, item.B, got, item.Want)
+		}
+	}
+}
+
+func TestVersionAffected(t *testing.T) {
+	events := []AdvisoryEvent{
+		{Introduced: "0"},
+		{Fixed: "v1.2.0"},
+		{Introduced: "v1.5.0"},
+		{Fixed: "v1.6.1"},
+	}
+	list := []struct {
+		Version  string
+		Affected bool
+		Fixed    string
+	}{
+		{"v1.0.0", true, "v1.2.0"},
+		{"v1.2.0", false, ""},
+		{"v1.4.9", false, ""},
+		{"v1.5.0", true, "v1.6.1"},
+		{"v1.6.1", false, ""},
+	}
+	for _, item := range list {
+		affected, fixed := versionAffected(item.Version, events)
+		if affected != item.Affected || fixed != item.Fixed {
+			t.Errorf("%s: got %t %q, want %t %q", item.Version, affected, fixed, item.Affected, item.Fixed)
+		}
+	}
+
+	affected, fixed := versionAffected("v1.3.0", []AdvisoryEvent{{Introduced: "v1.0.0"}, {LastAffected: "v1.3.0"}})
+	if !affected || fixed != "" {
+		t.Errorf("last affected: got %t %q", affected, fixed)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	repo := remote.Setup()
+	badRev, _ := repo.Commit()
+	writeFile(t, filepath.Join(g.Path("remote/co2/pk1"), "b.go"), "package pk1\n")
+	fixRev, _ := repo.Commit()
+	writeFile(t, filepath.Join(g.Path("remote/co2/pk1"), "c.go"), "package pk1\n")
+	reintroRev, _ := repo.Commit()
+	writeFile(t, filepath.Join(g.Path("remote/co2/pk1"), "d.go"), "package pk1\n")
+	fix2Rev, _ := repo.Commit()
+
+	g.In("co1")
+	c := ctx(g)
+	remotePkg := remote.HttpAddr() + "/remote/co2/pk1"
+	g.Check(c.ModifyImport(pkg(remotePkg+"@"+badRev), Fetch))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), AddUpdate))
+	g.Check(c.Alter())
+	c.VendorFilePackagePath("co3/pk1").VersionExact = "v1.1.0"
+
+	db := g.Path("osv")
+	g.Check(os.MkdirAll(db, 0777))
+	writeFile(t, filepath.Join(db, "GO-1.json"), `{
+	"id": "GO-1",
+	"summary": "version range",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "co3"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "v1.2.0"}]}]
+	}]
+}`)
+	writeFile(t, filepath.Join(db, "more.json"), `[{
+	"id": "GO-2",
+	"summary": "commit range",
+	"affected": [{
+		"ranges": [{"type": "GIT", "repo": "http://`+remote.HttpAddr()+`/remote/co2", "events": [{"introduced": "`+badRev+`"}, {"fixed": "`+fixRev+`"}]}]
+	}]
+}, {
+	"id": "GO-3",
+	"summary": "withdrawn",
+	"withdrawn": "2017-01-01T00:00:00Z",
+	"affected": [{"package": {"name": "co3/pk1"}, "versions": ["v1.1.0"]}]
+}, {
+	"id": "GO-4",
+	"summary": "fixed already",
+	"affected": [{
+		"package": {"name": "co3/pk1"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "v1.0.0"}]}]
+	}]
+}]`)
+	advisories, err := ReadAdvisories(db)
+	g.Check(err)
+	if len(advisories) != 4 {
+		t.Fatalf("expected 4 advisories, got %d", len(advisories))
+	}
+
+	found, err := c.Audit(advisories)
+	g.Check(err)
+	got := make(map[string]string, len(found))
+	for _, f := range found {
+		got[f.Advisory.ID] = f.Package.Path + " " + f.Fixed
+	}
+	want := map[string]string{
+		"GO-1": "co3/pk1 v1.2.0",
+		"GO-2": remotePkg + " " + fixRev,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("%s: got %q, want %q", id, got[id], w)
+		}
+	}
+
+	// After updating to the fix the commit range no longer matches.
+	g.Check(c.ModifyImport(pkg(remotePkg+"@"+fixRev), Fetch))
+	g.Check(c.Alter())
+	found, err = c.Audit(advisories[1:2])
+	g.Check(err)
+	if len(found) != 0 {
+		t.Fatalf("expected no findings after fix, got %d", len(found))
+	}
+
+	// A range introduced again after the fix matches revisions after that.
+	reintro := []*Advisory{{
+		ID: "GO-5",
+		Affected: []AdvisoryAffected{{
+			Ranges: []AdvisoryRange{{Type: "GIT", Repo: "http://" + remote.HttpAddr() + "/remote/co2", Events: []AdvisoryEvent{
+				{Introduced: badRev}, {Fixed: fixRev}, {Introduced: reintroRev}, {Fixed: fix2Rev},
+			}}},
+		}},
+	}}
+	found, err = c.Audit(reintro)
+	g.Check(err)
+	if len(found) != 0 {
+		t.Fatalf("expected no findings between the ranges, got %d", len(found))
+	}
+	g.Check(c.ModifyImport(pkg(remotePkg+"@"+reintroRev), Fetch))
+	g.Check(c.Alter())
+	found, err = c.Audit(reintro)
+	g.Check(err)
+	if len(found) != 1 || found[0].Fixed != fix2Rev {
+		t.Fatalf("expected re-introduced finding fixed in %s, got %+v", fix2Rev, found)
+	}
+}
@@ -0,0 +1,530 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/shlex"
+	"golang.org/x/tools/go/vcs"
+)
+
+// HostAuth configures how to authenticate to a single remote host.
+type HostAuth struct {
+	Host string `json:"host"` // Host name, with port if not the default.
+
+	// PreferSSH uses SSH rather than HTTPS for VCS commands.
+	PreferSSH bool   `json:"ssh,omitempty"`
+	SSHUser   string `json:"sshUser,omitempty"` // Defaults to "git".
+
+	// Helper is a command that prints credentials for the host.
+	// It follows the git credential helper protocol: it is called with
+	// the argument "get", is given "protocol" and "host" on stdin and
+	// prints "username" and "password" lines on stdout.
+	Helper string `json:"helper,omitempty"`
+}
+
+// Auth holds the credential settings used when accessing remote
+// repositories. Credentials are read from a host's helper first, then
+// from the netrc file. Credentials are only sent over HTTPS and are
+// never written to the vendor file or logs.
+type Auth struct {
+	Host  []*HostAuth
+	Netrc string // Path to the netrc file, empty to not use one.
+
+	lock  sync.Mutex
+	cache map[string]*credential
+}
+
+type credential struct {
+	User     string
+	Password string
+}
+
+// AuthFilePath returns the path of the per-user credential settings.
+// It may be set with the GOVENDOR_AUTH environment variable.
+func AuthFilePath() string {
+	if p := os.Getenv("GOVENDOR_AUTH"); len(p) > 0 {
+		return p
+	}
+	dir := userConfigDir()
+	if len(dir) == 0 {
+		return ""
+	}
+	return filepath.Join(dir, "govendor", "auth.json")
+}
+
+// homeDir returns the home folder of the user, or an empty string if
+// unknown.
+func homeDir() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("USERPROFILE")
+	}
+	return os.Getenv("HOME")
+}
+
+// userConfigDir returns the folder for per-user settings, or an empty
+// string if unknown.
+func userConfigDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		return os.Getenv("AppData")
+	case "darwin":
+		if home := homeDir(); len(home) > 0 {
+			return filepath.Join(home, "Library", "Application Support")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return dir
+	}
+	if home := homeDir(); len(home) > 0 {
+		return filepath.Join(home, ".config")
+	}
+	return ""
+}
+
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); len(p) > 0 {
+		return p
+	}
+	home := homeDir()
+	if len(home) == 0 {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// NewAuth reads the credential settings from the file returned from
+// AuthFilePath and uses the user's netrc file. It is not an error if
+// either file does not exist.
+func NewAuth() (*Auth, error) {
+	a := &Auth{
+		Netrc: netrcPath(),
+	}
+	p := AuthFilePath()
+	if len(p) == 0 {
+		return a, nil
+	}
+	bb, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return a, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(bb, &a.Host)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read credential settings %q: %v", p, err)
+	}
+	return a, nil
+}
+
+func (a *Auth) hostAuth(host string) *HostAuth {
+	if a == nil {
+		return nil
+	}
+	for _, ha := range a.Host {
+		if strings.EqualFold(ha.Host, host) {
+			return ha
+		}
+	}
+	return nil
+}
+
+// credential returns the user and password for host or nil if none are found.
+func (a *Auth) credential(host string) (*credential, error) {
+	if a == nil || len(host) == 0 {
+		return nil, nil
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if c, found := a.cache[host]; found {
+		return c, nil
+	}
+	var c *credential
+	var err error
+	if ha := a.hostAuth(host); ha != nil && len(ha.Helper) > 0 {
+		c, err = helperCredential(ha.Helper, host)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c == nil && len(a.Netrc) > 0 {
+		c, err = netrcCredential(a.Netrc, host)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a.cache == nil {
+		a.cache = make(map[string]*credential, 3)
+	}
+	a.cache[host] = c
+	return c, nil
+}
+
+func helperCredential(helper, host string) (*credential, error) {
+	args, err := shlex.Split(helper)
+	if err != nil || len(args) == 0 {
+		return nil, fmt.Errorf("Invalid credential helper for %q", host)
+	}
+	cmd := exec.Command(args[0], append(args[1:], "get")...)
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// Do not include the output, it may contain secrets.
+		return nil, fmt.Errorf("Credential helper for %q failed: %v", host, err)
+	}
+	c := &credential{}
+	scan := bufio.NewScanner(bytes.NewReader(out))
+	for scan.Scan() {
+		kv := strings.SplitN(scan.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "username":
+			c.User = kv[1]
+		case "password":
+			c.Password = kv[1]
+		}
+	}
+	if len(c.User) == 0 && len(c.Password) == 0 {
+		return nil, nil
+	}
+	return c, nil
+}
+
+func netrcCredential(netrc, host string) (*credential, error) {
+	bb, err := ioutil.ReadFile(netrc)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	hostname := host
+	if h, _, err := splitHostPort(host); err == nil {
+		hostname = h
+	}
+	var def *credential
+	for _, m := range parseNetrc(string(bb)) {
+		switch {
+		case m.machine == host || m.machine == hostname:
+			return &credential{User: m.login, Password: m.password}, nil
+		case m.isDefault && def == nil:
+			def = &credential{User: m.login, Password: m.password}
+		}
+	}
+	return def, nil
+}
+
+func splitHostPort(host string) (string, string, error) {
+	u, err := url.Parse("//" + host)
+	if err != nil {
+		return "", "", err
+	}
+	return u.Hostname(), u.Port(), nil
+}
+
+type netrcMachine struct {
+	machine   string
+	isDefault bool
+	login     string
+	password  string
+}
+
+// parseNetrc parses the machine entries of a netrc file.
+func parseNetrc(data string) []netrcMachine {
+	var list []netrcMachine
+	var m *netrcMachine
+	inMacro := false
+	for _, line := range strings.Split(data, "\n") {
+		if inMacro {
+			// A macro definition ends with an empty line.
+			if len(strings.TrimSpace(line)) == 0 {
+				inMacro = false
+			}
+			continue
+		}
+		f := strings.Fields(line)
+		for i := 0; i < len(f); i++ {
+			switch f[i] {
+			case "machine", "default":
+				if m != nil {
+					list = append(list, *m)
+				}
+				m = &netrcMachine{}
+				if f[i] == "default" {
+					m.isDefault = true
+					continue
+				}
+				if i+1 < len(f) {
+					i++
+					m.machine = f[i]
+				}
+			case "login", "password", "account":
+				if m == nil || i+1 >= len(f) {
+					continue
+				}
+				i++
+				switch f[i-1] {
+				case "login":
+					m.login = f[i]
+				case "password":
+					m.password = f[i]
+				}
+			case "macdef":
+				inMacro = true
+				i = len(f)
+			}
+		}
+	}
+	if m != nil {
+		list = append(list, *m)
+	}
+	return list
+}
+
+// authTransport adds basic authentication to HTTPS requests for hosts
+// with credentials.
+type authTransport struct {
+	auth *Auth
+	base http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" && req.Header.Get("Authorization") == "" {
+		c, err := t.auth.credential(req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			next := new(http.Request)
+			*next = *req
+			next.Header = make(http.Header, len(req.Header)+1)
+			for k, v := range req.Header {
+				next.Header[k] = v
+			}
+			next.SetBasicAuth(c.User, c.Password)
+			req = next
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Error text of vcs.RepoRootForImportPathStatic when a meta lookup is needed.
+const errUnknownSiteText = "dynamic lookup required to find mapping"
+
+// repoRootForImportPath is vcs.RepoRootForImportPath that uses credentials
+// for the meta lookup and applies the host SSH preference to the repo.
+// Unlike vcs.RepoRootForImportPath, a failed meta request is reported
+// rather than squelched so it may be retried. Errors that will not
+// change when retried are returned as a permanentError.
+func (a *Auth) repoRootForImportPath(importPath string) (*vcs.RepoRoot, error) {
+	rr, err := vcs.RepoRootForImportPathStatic(importPath, "")
+	if err != nil && err.Error() == errUnknownSiteText {
+		rr, err = a.repoRootDynamic(importPath)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "http/https fetch") {
+				return nil, fmt.Errorf("unable to reach %q: %v", importPath, err)
+			}
+			err = fmt.Errorf("unrecognized import path %q", importPath)
+		}
+	}
+	if err == nil && strings.Contains(importPath, "...") && strings.Contains(rr.Root, "...") {
+		// Do not allow wildcards in the repo root.
+		err = fmt.Errorf("cannot expand ... in %q", importPath)
+	}
+	if err != nil {
+		return nil, permanentError{err}
+	}
+	rr.Repo = a.repoURL(rr.VCS, rr.Repo)
+	return rr, nil
+}
+
+// repoRoot resolves importPath, retrying with the context retry policy.
+func (ctx *Context) repoRoot(importPath string) (*vcs.RepoRoot, error) {
+	var rr *vcs.RepoRoot
+	span := ctx.startEvent(Event{Kind: EventResolve, Package: importPath})
+	err := ctx.retry("resolve "+importPath, func() error {
+		var err error
+		rr, err = ctx.Auth.repoRootForImportPath(importPath)
+		return err
+	})
+	if rr != nil {
+		span.Repo = rr.Repo
+		span.Path = rr.Root
+	}
+	span.finish(0, err)
+	return rr, err
+}
+
+var sshScheme = map[string]string{
+	"git": "ssh",
+	"hg":  "ssh",
+	"bzr": "bzr+ssh",
+	"svn": "svn+ssh",
+}
+
+// repoURL rewrites an HTTPS repo URL to an SSH URL if the host prefers SSH.
+func (a *Auth) repoURL(cmd *vcs.Cmd, repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return repo
+	}
+	ha := a.hostAuth(u.Host)
+	if ha == nil || !ha.PreferSSH {
+		return repo
+	}
+	scheme := sshScheme[cmd.Cmd]
+	if len(scheme) == 0 {
+		return repo
+	}
+	user := ha.SSHUser
+	if len(user) == 0 {
+		user = "git"
+	}
+	u.Scheme = scheme
+	u.User = url.User(user)
+	// Use the default SSH port, not the HTTP one.
+	u.Host = u.Hostname()
+	return u.String()
+}
+
+// setup adds any credentials needed for repo to the VCS command environment.
+// If repo is empty, the remote repo of dir is used.
+func (a *Auth) setup(vcsCmd *VCSCmd, repo, dir string) error {
+	if a == nil {
+		return nil
+	}
+	if len(repo) == 0 {
+		var err error
+		repo, err = vcsCmd.RemoteRepo(dir)
+		if err != nil {
+			// Unable to find the remote, continue without credentials.
+			return nil
+		}
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.Scheme != "https" {
+		return nil
+	}
+	c, err := a.credential(u.Host)
+	if err != nil || c == nil {
+		return err
+	}
+	// Bazaar only reads HTTPS credentials from its own authentication.conf.
+	switch vcsCmd.Cmd.Cmd {
+	case "git":
+		// Scope the header to the host with URL specific configuration.
+		token := base64.StdEncoding.EncodeToString([]byte(c.User + ":" + c.Password))
+		key := "http.https://" + u.Host + "/.extraHeader"
+		value := "Authorization: Basic " + token
+		if !gitConfigEnv() {
+			vcsCmd.Args = append(vcsCmd.Args, "-c", key+"="+value)
+			break
+		}
+		// Keep any configuration the user set in the environment.
+		n := gitConfigCount(vcsCmd.Env)
+		vcsCmd.Env = append(vcsCmd.Env,
+			fmt.Sprintf("GIT_CONFIG_COUNT=%d", n+1),
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", n, key),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", n, value),
+		)
+	case "hg":
+		const section = "auth.govendor."
+		vcsCmd.Args = append(vcsCmd.Args,
+			"--config", section+"prefix=https://"+u.Host,
+			"--config", section+"username="+c.User,
+			"--config", section+"password="+c.Password,
+		)
+	case "svn":
+		// Don't let svn save the password in its own cache.
+		vcsCmd.Args = append(vcsCmd.Args,
+			"--username", c.User,
+			"--password", c.Password,
+			"--no-auth-cache",
+			"--non-interactive",
+		)
+	}
+	return nil
+}
+
+var (
+	gitConfigEnvOnce sync.Once
+	gitConfigEnvOK   bool
+)
+
+// gitConfigEnv reports if git reads configuration from GIT_CONFIG_COUNT,
+// added in git 2.31. Older versions are given the configuration with -c.
+func gitConfigEnv() bool {
+	gitConfigEnvOnce.Do(func() {
+		out, err := exec.Command("git", "version").Output()
+		if err != nil {
+			return
+		}
+		gitConfigEnvOK = gitVersionAtLeast(string(out), 2, 31)
+	})
+	return gitConfigEnvOK
+}
+
+// gitVersionAtLeast reports if the output of "git version", such as
+// "git version 2.30.1.windows.1", is at least major.minor.
+func gitVersionAtLeast(out string, major, minor int) bool {
+	f := strings.Fields(out)
+	if len(f) < 3 {
+		return false
+	}
+	parts := strings.SplitN(f[2], ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	ma, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	mi, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return ma > major || (ma == major && mi >= minor)
+}
+
+// gitConfigCount returns the number of git configuration entries set in
+// env, or else in the process environment.
+func gitConfigCount(env []string) int {
+	const key = "GIT_CONFIG_COUNT="
+	count := os.Getenv("GIT_CONFIG_COUNT")
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key) {
+			count = kv[len(key):]
+		}
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
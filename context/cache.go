@@ -0,0 +1,164 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kardianos/govendor/internal/pathos"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// Cache is the folder remote repos are fetched into.
+type Cache struct {
+	Root string
+
+	ctx *Context // Stops VCS commands on interrupt or timeout, may be nil.
+}
+
+// CacheRepo is a repo in the cache.
+type CacheRepo struct {
+	Path     string    // Repo root import path, relative to the cache root.
+	Dir      string    // Full path to the repo.
+	VCS      *vcs.Cmd  // Version control system of the repo.
+	Size     int64     // Total size of all files in bytes.
+	LastUsed time.Time // Last time fetch or sync used the repo.
+}
+
+// CacheRoot returns the folder remote repos are fetched into.
+func (ctx *Context) CacheRoot() string {
+	if len(ctx.cacheRoot) > 0 {
+		return ctx.cacheRoot
+	}
+	// GOPATH here includes the "src" dir, go up one level.
+	return filepath.Join(ctx.RootGopath, "..", ".cache", "govendor")
+}
+
+// Cache returns the repo cache of the context.
+func (ctx *Context) Cache() *Cache {
+	return &Cache{Root: ctx.CacheRoot(), ctx: ctx}
+}
+
+// The VCS commands that may be used in the cache. The repo metadata is
+// stored in a folder of the command name with a "." prefix.
+var cacheVcsList = []string{"git", "hg", "bzr", "svn"}
+
+// touchCacheRepo records the repo in dir was used. The modification time
+// of the repo folder is used as the last use time.
+func touchCacheRepo(dir string) {
+	now := time.Now()
+	os.Chtimes(dir, now, now)
+}
+
+// List returns the repos in the cache sorted by path.
+func (c *Cache) List() ([]*CacheRepo, error) {
+	var list []*CacheRepo
+	err := filepath.Walk(c.Root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == c.Root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		for _, name := range cacheVcsList {
+			if _, err := os.Stat(filepath.Join(p, "."+name)); err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(c.Root, p)
+			if err != nil {
+				return err
+			}
+			repo := &CacheRepo{
+				Path:     pathos.SlashToImportPath(rel),
+				Dir:      p,
+				VCS:      vcs.ByCmd(name),
+				LastUsed: info.ModTime(),
+			}
+			repo.Size, err = dirSize(p)
+			if err != nil {
+				return err
+			}
+			list = append(list, repo)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	return list, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Find returns the cached repo that contains the package or repo path.
+func (c *Cache) Find(path string) (*CacheRepo, error) {
+	path = strings.Trim(path, "/")
+	list, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range list {
+		if repo.Path == path || strings.HasPrefix(path, repo.Path+"/") {
+			return repo, nil
+		}
+	}
+	return nil, fmt.Errorf("Repo %q not found in cache %q", path, c.Root)
+}
+
+// Verify runs the VCS integrity check on the repo.
+func (c *Cache) Verify(repo *CacheRepo) error {
+	vcsCmd := updateVcsCmd(repo.VCS)
+	if c.ctx != nil {
+		vcsCmd = c.ctx.newVcsCmd(repo.VCS)
+	}
+	return vcsCmd.Verify(repo.Dir)
+}
+
+// Remove deletes the repo from the cache. It will be fetched again
+// when next needed.
+func (c *Cache) Remove(repo *CacheRepo) error {
+	err := os.RemoveAll(repo.Dir)
+	if err != nil {
+		return err
+	}
+	// Remove the marker of an abandoned create with the repo.
+	err = os.Remove(repo.Dir + partialSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Remove any parent folders left empty, but not the cache root.
+	root := filepath.Clean(c.Root)
+	for dir := filepath.Dir(repo.Dir); len(dir) > len(root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestCache(t *testing.T) {
+	execPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("unsupported vcs")
+	}
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	cache := c.Cache()
+
+	for _, p := range []string{"example.com/a/b", "example.com/a/c", "example.org/d"} {
+		dir := filepath.Join(cache.Root, filepath.FromSlash(p))
+		out, err := exec.Command(execPath, "init", "-q", dir).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+		writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	}
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	g.Check(os.Chtimes(filepath.Join(cache.Root, "example.org", "d"), old, old))
+
+	repos, err := cache.List()
+	g.Check(err)
+	if len(repos) != 3 {
+		t.Fatalf("got %d repos, want 3", len(repos))
+	}
+	want := []string{"example.com/a/b", "example.com/a/c", "example.org/d"}
+	for i, repo := range repos {
+		if repo.Path != want[i] {
+			t.Errorf("got repo %q, want %q", repo.Path, want[i])
+		}
+		if repo.VCS.Cmd != "git" || repo.Size == 0 {
+			t.Errorf("%s: unexpected vcs %q or size %d", repo.Path, repo.VCS.Cmd, repo.Size)
+		}
+		g.Check(cache.Verify(repo))
+	}
+	if !repos[2].LastUsed.Before(time.Now().Add(-90 * 24 * time.Hour)) {
+		t.Errorf("unexpected last used time %v", repos[2].LastUsed)
+	}
+
+	repo, err := cache.Find("example.com/a/c/sub/pkg")
+	g.Check(err)
+	if repo.Path != "example.com/a/c" {
+		t.Fatalf("found %q", repo.Path)
+	}
+	if _, err = cache.Find("example.com/a"); err == nil {
+		t.Fatal("expected repo to not be found")
+	}
+
+	// Verify runs with the command timeout of the context.
+	c.CommandTimeout = time.Nanosecond
+	if err = cache.Verify(repos[0]); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected verify to time out, got %v", err)
+	}
+	c.CommandTimeout = 0
+
+	// The marker of an abandoned create is removed with the repo.
+	writeFile(t, repos[2].Dir+partialSuffix, "")
+	g.Check(cache.Remove(repos[2]))
+	g.Check(cache.Remove(repo))
+	if _, err = os.Stat(filepath.Join(cache.Root, "example.org")); !os.IsNotExist(err) {
+		t.Fatal("expected empty parent folder to be removed")
+	}
+	repos, err = cache.List()
+	g.Check(err)
+	if len(repos) != 1 || repos[0].Path != "example.com/a/b" {
+		t.Fatalf("unexpected repos after remove %v", repos)
+	}
+}
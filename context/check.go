@@ -0,0 +1,284 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"golang.org/x/tools/go/vcs"
+
+	os "github.com/kardianos/govendor/internal/vos"
+)
+
+// The checks run by RunChecks.
+const (
+	CheckMissing     = "missing"     // Referenced packages that are not found.
+	CheckUnused      = "unused"      // Vendor packages that are not referenced.
+	CheckExternal    = "external"    // Packages used from GOPATH, not vendored.
+	CheckFiles       = "files"       // Vendor file packages without files.
+	CheckChecksum    = "checksum"    // Vendor packages that don't match the checksum.
+	CheckUncommitted = "uncommitted" // Vendor file packages without a checksum.
+	CheckDuplicate   = "duplicate"   // Vendor file paths or origins listed more than once.
+	CheckRevision    = "revision"    // Repositories pinned to several revisions.
+)
+
+// CheckList is every check in the order they are run.
+var CheckList = []string{
+	CheckMissing,
+	CheckUnused,
+	CheckExternal,
+	CheckFiles,
+	CheckChecksum,
+	CheckUncommitted,
+	CheckDuplicate,
+	CheckRevision,
+}
+
+// CheckProblem is a problem found by a check.
+type CheckProblem struct {
+	Package string // Import path of the package, or repository root.
+	Message string
+}
+
+// CheckResult lists the problems found by a check.
+type CheckResult struct {
+	Name     string
+	Problems []CheckProblem
+}
+
+// RunChecks runs the named checks in CheckList order and returns the result
+// of each one. A check passes if it has no problems.
+func (ctx *Context) RunChecks(names []string) ([]CheckResult, error) {
+	run := make(map[string]bool, len(names))
+	for _, name := range names {
+		found := false
+		for _, c := range CheckList {
+			if c == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown check %q, expected one of %s", name, strings.Join(CheckList, ", "))
+		}
+		run[name] = true
+	}
+
+	var status []StatusItem
+	if run[CheckMissing] || run[CheckUnused] || run[CheckExternal] {
+		var err error
+		status, err = ctx.Status()
+		if err != nil {
+			return nil, err
+		}
+	}
+	noFiles := ctx.checkFiles()
+	results := make([]CheckResult, 0, len(run))
+	for _, name := range CheckList {
+		if !run[name] {
+			continue
+		}
+		var problems []CheckProblem
+		switch name {
+		case CheckMissing:
+			problems = checkStatus(status, func(item StatusItem) string {
+				// Vendor file packages without files are reported by CheckFiles.
+				if item.Status.Presence != PresenceMissing || len(item.ImportedBy) == 0 {
+					return ""
+				}
+				return "missing, imported by " + importedBy(item)
+			})
+		case CheckUnused:
+			problems = checkStatus(status, func(item StatusItem) string {
+				if item.Status.Location != LocationVendor || item.Status.Presence != PresenceUnused {
+					return ""
+				}
+				return "vendored but not used"
+			})
+		case CheckExternal:
+			vendored := make(map[string]bool)
+			for _, item := range status {
+				if item.Status.Location == LocationVendor {
+					vendored[item.Pkg.Path] = true
+				}
+			}
+			problems = checkStatus(status, func(item StatusItem) string {
+				if item.Status.Location != LocationExternal || vendored[item.Pkg.Path] {
+					return ""
+				}
+				return "used from GOPATH, imported by " + importedBy(item)
+			})
+		case CheckFiles:
+			for _, vp := range noFiles {
+				problems = append(problems, CheckProblem{Package: vp, Message: "no files in the vendor folder"})
+			}
+		case CheckChecksum:
+			list, err := ctx.VerifyVendor()
+			if err != nil {
+				return nil, err
+			}
+			for _, vp := range list {
+				if uncommitted(vp.ChecksumSHA1, vp.ChecksumSHA256) || containsString(noFiles, vp.Path) {
+					continue
+				}
+				problems = append(problems, CheckProblem{Package: vp.Path, Message: "modified, does not match the checksum"})
+			}
+		case CheckUncommitted:
+			for _, vp := range ctx.VendorFile.Package {
+				if vp.Remove || !uncommitted(vp.ChecksumSHA1, vp.ChecksumSHA256) {
+					continue
+				}
+				problems = append(problems, CheckProblem{Package: vp.Path, Message: "no checksum, added uncommitted"})
+			}
+		case CheckDuplicate:
+			problems = ctx.checkDuplicate()
+		case CheckRevision:
+			problems = ctx.checkRevision()
+		}
+		results = append(results, CheckResult{Name: name, Problems: problems})
+	}
+	return results, nil
+}
+
+func checkStatus(status []StatusItem, problem func(item StatusItem) string) []CheckProblem {
+	var list []CheckProblem
+	for _, item := range status {
+		if msg := problem(item); len(msg) > 0 {
+			list = append(list, CheckProblem{Package: item.Local, Message: msg})
+		}
+	}
+	return list
+}
+
+func importedBy(item StatusItem) string {
+	names := make([]string, len(item.ImportedBy))
+	for i, pkg := range item.ImportedBy {
+		names[i] = pkg.Local
+	}
+	return strings.Join(names, ", ")
+}
+
+func uncommitted(sha1, sha256 string) bool {
+	return (len(sha1) == 0 && len(sha256) == 0) || strings.HasPrefix(sha1, "uncommitted/")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFiles returns the vendor file packages without any files in the
+// vendor folder. The files of a tree package may be in any sub-folder.
+func (ctx *Context) checkFiles() []string {
+	var list []string
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Path) == 0 {
+			continue
+		}
+		if !hasFiles(filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path)), vp.Tree) {
+			list = append(list, vp.Path)
+		}
+	}
+	return list
+}
+
+// hasFiles reports if the folder has any files, including in sub-folders
+// if tree is set.
+func hasFiles(folder string, tree bool) bool {
+	dir, err := os.Open(folder)
+	if err != nil {
+		return false
+	}
+	fl, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		return false
+	}
+	for _, fi := range fl {
+		if !fi.IsDir() {
+			return true
+		}
+	}
+	if !tree {
+		return false
+	}
+	for _, fi := range fl {
+		if fi.IsDir() && hasFiles(filepath.Join(folder, fi.Name()), true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *Context) checkDuplicate() []CheckProblem {
+	var list []CheckProblem
+	paths := make(map[string]int)
+	origins := make(map[string][]string)
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		paths[vp.Path]++
+		if len(vp.Origin) > 0 {
+			origins[vp.Origin] = append(origins[vp.Origin], vp.Path)
+		}
+	}
+	for _, vp := range ctx.VendorFile.Package {
+		if n := paths[vp.Path]; n > 1 {
+			list = append(list, CheckProblem{Package: vp.Path, Message: fmt.Sprintf("listed %d times", n)})
+			paths[vp.Path] = 0
+		}
+	}
+	for origin, used := range origins {
+		if len(used) > 1 {
+			list = append(list, CheckProblem{Package: origin, Message: "origin of " + strings.Join(used, ", ")})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Package < list[j].Package })
+	return list
+}
+
+// checkRevision finds the repositories pinned to more than one revision.
+// Only repositories known without a network request are checked.
+func (ctx *Context) checkRevision() []CheckProblem {
+	revisions := make(map[string]map[string][]string) // map[root]map[revision][]path
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Revision) == 0 {
+			continue
+		}
+		rr, err := vcs.RepoRootForImportPathStatic(vp.PathOrigin(), "")
+		if err != nil {
+			continue
+		}
+		byRev := revisions[rr.Root]
+		if byRev == nil {
+			byRev = make(map[string][]string)
+			revisions[rr.Root] = byRev
+		}
+		byRev[vp.Revision] = append(byRev[vp.Revision], vp.Path)
+	}
+	var list []CheckProblem
+	for root, byRev := range revisions {
+		if len(byRev) < 2 {
+			continue
+		}
+		pinned := make([]string, 0, len(byRev))
+		for rev, paths := range byRev {
+			pinned = append(pinned, fmt.Sprintf("%s (%s)", rev, strings.Join(paths, ", ")))
+		}
+		sort.Strings(pinned)
+		list = append(list, CheckProblem{Package: root, Message: "pinned to revisions " + strings.Join(pinned, "; ")})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Package < list[j].Package })
+	return list
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+func TestRunChecks(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1", "co4/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co5/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.ModifyImport(pkg("co5/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	dup := *c.VendorFilePackagePath("co2/pk1")
+	c.VendorFile.Package = append(c.VendorFile.Package,
+		&dup,
+		&vendorfile.Package{Path: "co6/pk1"},
+		&vendorfile.Package{Path: "github.com/a/b/c", Origin: "github.com/fork/b/c", Revision: "r1", ChecksumSHA1: "x"},
+		&vendorfile.Package{Path: "github.com/a/b/d", Origin: "github.com/fork/b/c", Revision: "r2", ChecksumSHA1: "y"},
+	)
+	g.Check(ioutil.WriteFile(filepath.Join(g.Current(), "vendor", "co5", "pk1", "a.go"), []byte("package pk1\n"), 0666))
+
+	results, err := c.RunChecks(CheckList)
+	g.Check(err)
+	buf := &bytes.Buffer{}
+	for _, res := range results {
+		fmt.Fprintf(buf, "%s\n", res.Name)
+		for _, p := range res.Problems {
+			fmt.Fprintf(buf, "\t%s: %s\n", p.Package, p.Message)
+		}
+	}
+	expected := `missing
+	co3/pk1: missing, imported by co1/pk1
+unused
+	co1/vendor/co5/pk1: vendored but not used
+external
+	co4/pk1: used from GOPATH, imported by co1/pk1
+files
+	co6/pk1: no files in the vendor folder
+	github.com/a/b/c: no files in the vendor folder
+	github.com/a/b/d: no files in the vendor folder
+checksum
+	co5/pk1: modified, does not match the checksum
+uncommitted
+	co6/pk1: no checksum, added uncommitted
+duplicate
+	co2/pk1: listed 2 times
+	github.com/fork/b/c: origin of github.com/a/b/c, github.com/a/b/d
+revision
+	github.com/fork/b: pinned to revisions r1 (github.com/a/b/c); r2 (github.com/a/b/d)
+`
+	if buf.String() != expected {
+		t.Fatalf("Got\n%s", buf.String())
+	}
+
+	_, err = c.RunChecks([]string{"bad"})
+	if err == nil {
+		t.Fatal("expected unknown check error")
+	}
+}
+
+func TestCheckFilesTree(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	sub := filepath.Join(g.Current(), "vendor", "co2", "pk1", "sub")
+	g.Check(os.MkdirAll(sub, 0777))
+	g.Check(ioutil.WriteFile(filepath.Join(sub, "a.go"), []byte("package sub\n"), 0666))
+	vp := &vendorfile.Package{Path: "co2/pk1", Tree: true}
+	c.VendorFile.Package = append(c.VendorFile.Package, vp)
+	if list := c.checkFiles(); len(list) != 0 {
+		t.Fatalf("tree package reported without files %q", list)
+	}
+	vp.Tree = false
+	if list := c.checkFiles(); len(list) != 1 {
+		t.Fatalf("package without files not reported")
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// Checksum algorithm names used in the vendor file "requireChecksum" field.
+const (
+	ChecksumSHA1   = "sha1"
+	ChecksumSHA256 = "sha256"
+)
+
+// defaultRequireChecksum is used if the vendor file doesn't set which
+// checksums are required.
+var defaultRequireChecksum = []string{ChecksumSHA1}
+
+// packageHash computes each package checksum in a single pass. It also
+// records the hash of each file for the manifest.
+type packageHash struct {
+	sha1   hash.Hash
+	sha256 hash.Hash
+
+	file     hash.Hash         // Hash of the current file content, nil between files.
+	fileName string            // Import path of the current file.
+	files    map[string]string // File import path to base64 SHA-256.
+	n        int64             // Bytes written since the last Reset.
+}
+
+func newPackageHash() *packageHash {
+	return &packageHash{
+		sha1:   sha1.New(),
+		sha256: sha256.New(),
+		files:  make(map[string]string, 10),
+	}
+}
+
+func (h *packageHash) Write(p []byte) (int, error) {
+	if h.file != nil {
+		h.file.Write(p)
+	}
+	h.sha1.Write(p)
+	h.n += int64(len(p))
+	return h.sha256.Write(p)
+}
+
+func (h *packageHash) Reset() {
+	h.sha1.Reset()
+	h.sha256.Reset()
+	h.file = nil
+	h.files = make(map[string]string, 10)
+	h.n = 0
+}
+
+// fileHasher is implemented by hash writers that record the hash of each
+// file. Hash writers are passed as an io.Writer to CopyPackage and getHash.
+type fileHasher interface {
+	beginFile(name string)
+	endFile()
+}
+
+// beginFile starts recording the content of the file with the import
+// path name.
+func (h *packageHash) beginFile(name string) {
+	h.file = sha256.New()
+	h.fileName = name
+}
+
+// endFile records the hash of the current file.
+func (h *packageHash) endFile() {
+	if h.file == nil {
+		return
+	}
+	h.files[h.fileName] = base64.StdEncoding.EncodeToString(h.file.Sum(nil))
+	h.file = nil
+}
+
+// manifest returns the hash of each file in the package at pkgPath.
+// File names are relative to the package.
+func (h *packageHash) manifest(pkgPath string) *vendorfile.ManifestPackage {
+	mp := &vendorfile.ManifestPackage{
+		Path:  pkgPath,
+		Files: make(map[string]string, len(h.files)),
+	}
+	prefix := strings.Trim(pkgPath, "/") + "/"
+	for name, sum := range h.files {
+		mp.Files[strings.TrimPrefix(name, prefix)] = sum
+	}
+	return mp
+}
+
+// checksums returns the base64 encoded checksum of each algorithm.
+func (h *packageHash) checksums() map[string]string {
+	return map[string]string{
+		ChecksumSHA1:   base64.StdEncoding.EncodeToString(h.sha1.Sum(nil)),
+		ChecksumSHA256: base64.StdEncoding.EncodeToString(h.sha256.Sum(nil)),
+	}
+}
+
+// set records the checksums in the vendor file package.
+func (h *packageHash) set(vp *vendorfile.Package) {
+	sums := h.checksums()
+	vp.ChecksumSHA1 = sums[ChecksumSHA1]
+	vp.ChecksumSHA256 = sums[ChecksumSHA256]
+}
+
+// setChecksum records the checksums in the vendor file package and the
+// file hashes in the manifest.
+func (ctx *Context) setChecksum(vp *vendorfile.Package, h *packageHash) {
+	h.set(vp)
+	if ctx.manifest != nil {
+		ctx.manifest.Set(h.manifest(vp.Path))
+	}
+}
+
+// packageChecksums returns the checksum of each algorithm recorded for
+// the vendor file package.
+func packageChecksums(vp *vendorfile.Package) map[string]string {
+	return map[string]string{
+		ChecksumSHA1:   vp.ChecksumSHA1,
+		ChecksumSHA256: vp.ChecksumSHA256,
+	}
+}
+
+// parseRequireChecksum parses the space separated list of required
+// checksum algorithms.
+func parseRequireChecksum(s string) ([]string, error) {
+	list := strings.Fields(strings.ToLower(s))
+	if len(list) == 0 {
+		return defaultRequireChecksum, nil
+	}
+	for _, name := range list {
+		switch name {
+		case ChecksumSHA1, ChecksumSHA256:
+		default:
+			return nil, fmt.Errorf("Unknown checksum algorithm %q in vendor file, must be %q or %q", name, ChecksumSHA1, ChecksumSHA256)
+		}
+	}
+	return list, nil
+}
+
+// checksumMatch reports if the vendor file package checksums match the
+// computed package hash. Each required checksum must be present. Other
+// checksums must match if present.
+func (ctx *Context) checksumMatch(vp *vendorfile.Package, h *packageHash) bool {
+	have := packageChecksums(vp)
+	for _, name := range ctx.requireChecksum {
+		if len(have[name]) == 0 {
+			return false
+		}
+	}
+	for name, sum := range h.checksums() {
+		if len(have[name]) > 0 && have[name] != sum {
+			return false
+		}
+	}
+	return true
+}
+
+// hashVendorPackage computes the hash of the package in the vendor folder.
+// During Alter the staged vendor folder is used.
+func (ctx *Context) hashVendorPackage(vp *vendorfile.Package) (h *packageHash, err error) {
+	root := ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder))
+	fp := filepath.Join(root, pathos.SlashToFilepath(vp.Path))
+	h = newPackageHash()
+	span := ctx.startEvent(Event{Kind: EventChecksum, Package: vp.Path, Path: fp, Revision: vp.Revision})
+	defer func() {
+		span.Files = len(h.files)
+		span.finish(h.n, err)
+	}()
+	sk := skipperPackage
+	if vp.Tree {
+		sk = skipperTree
+	}
+	// Pruned files are not part of the package, even if still in the folder.
+	rules := ctx.pruneRules(vp.Path)
+	prefix := strings.Trim(vp.Path, "/") + "/"
+	extra := make(map[string]bool)
+	for _, rel := range strings.Fields(vp.ExtraFiles) {
+		extra[rel] = true
+	}
+	skip := func(rel string, isDir bool) bool {
+		if sk(rel, isDir) {
+			return true
+		}
+		pkgRel := strings.TrimPrefix(rel, prefix)
+		if extra[pkgRel] {
+			return true
+		}
+		if isDir && vp.Tree && rules.skipDir(pkgRel, true) {
+			// Only extra files may be in folders the copy skips.
+			return true
+		}
+		return rules.prune(pkgRel, isDir)
+	}
+	err = getHash(root, fp, h, skip)
+	if err != nil {
+		return h, err
+	}
+	return h, hashExtraFiles(root, vp, h)
+}
+
+// UpgradeChecksum fills in any missing checksums of the vendor file
+// packages, and the file manifest if used. A package is only upgraded if
+// the checksums it has match the vendor folder, otherwise it is returned
+// in modified. The vendor file is not written.
+func (ctx *Context) UpgradeChecksum() (upgraded, modified []*vendorfile.Package, err error) {
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Path) == 0 {
+			continue
+		}
+		have := packageChecksums(vp)
+		h, err := ctx.hashVendorPackage(vp)
+		if err != nil {
+			return nil, nil, err
+		}
+		sums := h.checksums()
+		noManifest := ctx.manifest != nil && ctx.manifest.Find(vp.Path) == nil
+		match, missing := 0, 0
+		for name, sum := range sums {
+			switch {
+			case len(have[name]) == 0:
+				missing++
+			case have[name] == sum:
+				match++
+			}
+		}
+		switch {
+		case match+missing != len(sums), match == 0:
+			// A checksum differs or there is none to verify the package with.
+			modified = append(modified, vp)
+		case missing > 0, noManifest:
+			ctx.setChecksum(vp, h)
+			upgraded = append(upgraded, vp)
+		}
+	}
+	return upgraded, modified, nil
+}
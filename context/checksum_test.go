@@ -0,0 +1,164 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestUpgradeChecksum(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("b.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), AddUpdate))
+	g.Check(c.Alter())
+
+	// Entries written before SHA-256 only have the SHA-1 checksum.
+	vp := c.VendorFile.Package[0]
+	sha256 := vp.ChecksumSHA256
+	vp.ChecksumSHA256 = ""
+	c.VendorFile.RequireChecksum = "sha1 sha256"
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	outOfDate, err := c.VerifyVendor()
+	g.Check(err)
+	if len(outOfDate) != 1 {
+		t.Fatalf("expected missing sha256 to fail verify, got %d packages", len(outOfDate))
+	}
+
+	upgraded, modified, err := c.UpgradeChecksum()
+	g.Check(err)
+	if len(upgraded) != 1 || len(modified) != 0 {
+		t.Fatalf("expected 1 upgraded and 0 modified, got %d and %d", len(upgraded), len(modified))
+	}
+	if got := c.VendorFile.Package[0].ChecksumSHA256; got != sha256 {
+		t.Fatalf("upgraded checksum %q, want %q", got, sha256)
+	}
+	verifyChecksum(g, c, "after upgrade")
+
+	// A modified package is not upgraded.
+	c.VendorFile.Package[0].ChecksumSHA256 = ""
+	writeFile(t, filepath.Join(g.Current(), "vendor", "co2", "pk1", "b.go"), "package pk1\n")
+	upgraded, modified, err = c.UpgradeChecksum()
+	g.Check(err)
+	if len(upgraded) != 0 || len(modified) != 1 {
+		t.Fatalf("expected 0 upgraded and 1 modified, got %d and %d", len(upgraded), len(modified))
+	}
+	if c.VendorFile.Package[0].ChecksumSHA256 != "" {
+		t.Fatal("modified package checksum was upgraded")
+	}
+
+	// A checksum that doesn't match fails even if not required.
+	c.VendorFile.Package[0].ChecksumSHA256 = sha256
+	c.requireChecksum = []string{ChecksumSHA1}
+	c.VendorFile.Package[0].ChecksumSHA1 = "x"
+	outOfDate, err = c.VerifyVendor()
+	g.Check(err)
+	if len(outOfDate) != 1 {
+		t.Fatalf("expected mismatched checksum to fail verify, got %d packages", len(outOfDate))
+	}
+}
+
+func TestManifest(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), AddUpdate))
+	g.Check(c.Alter())
+
+	// Enable the manifest for an existing vendor folder.
+	c.VendorFile.Manifest = "vendor.manifest.json"
+	g.Check(c.WriteVendorFile())
+	c = ctx(g)
+	list, err := c.VerifyVendorDetail()
+	g.Check(err)
+	if len(list) != 0 {
+		t.Fatalf("expected no mismatch, got %d", len(list))
+	}
+	upgraded, _, err := c.UpgradeChecksum()
+	g.Check(err)
+	if len(upgraded) != 1 {
+		t.Fatalf("expected manifest to be upgraded, got %d", len(upgraded))
+	}
+	g.Check(c.ModifyImport(pkg("co3/pk1"), AddUpdate))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	if len(c.manifest.Package) != 2 {
+		t.Fatalf("expected 2 manifest packages, got %d", len(c.manifest.Package))
+	}
+	mp := c.manifest.Find("co2/pk1")
+	if mp == nil || len(mp.Files) != 2 || len(mp.Files["a.go"]) == 0 {
+		t.Fatalf("unexpected co2/pk1 manifest %v", mp)
+	}
+
+	pkgDir := filepath.Join(g.Current(), "vendor", "co2", "pk1")
+	writeFile(t, filepath.Join(pkgDir, "a.go"), "package pk1\n")
+	writeFile(t, filepath.Join(pkgDir, "c.go"), "package pk1\n")
+	g.Check(os.Remove(filepath.Join(pkgDir, "b.go")))
+
+	list, err = c.VerifyVendorDetail()
+	g.Check(err)
+	if len(list) != 1 {
+		t.Fatalf("expected 1 mismatch, got %d", len(list))
+	}
+	m := list[0]
+	got := fmt.Sprintf("%t %q %q %q", m.HasManifest, m.Added, m.Removed, m.Modified)
+	want := `true ["c.go"] ["b.go"] ["a.go"]`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	// Removed packages are removed from the manifest.
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Remove))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+	c = ctx(g)
+	if len(c.manifest.Package) != 1 || c.manifest.Find("co3/pk1") != nil {
+		t.Fatalf("expected co3/pk1 to be removed from the manifest")
+	}
+}
+
+func TestParseRequireChecksum(t *testing.T) {
+	list, err := parseRequireChecksum("")
+	if err != nil || len(list) != 1 || list[0] != ChecksumSHA1 {
+		t.Fatalf("default: %q %v", list, err)
+	}
+	list, err = parseRequireChecksum("SHA1  sha256")
+	if err != nil || len(list) != 2 || list[1] != ChecksumSHA256 {
+		t.Fatalf("both: %q %v", list, err)
+	}
+	_, err = parseRequireChecksum("md5")
+	if err == nil {
+		t.Fatal("expected error for unknown algorithm")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/vcs"
+)
+
+// Candidate describes one of the packages in a conflict so the user can
+// compare them before choosing.
+type Candidate struct {
+	OpIndex  int    // Index of the operation in the conflict.
+	Local    string // Local path relative to $GOPATH/src.
+	Source   string // Local path without the canonical path, empty if the same.
+	Revision string // Revision of the package, empty if unknown.
+	Dirty    bool   // True if the package has uncommitted changes.
+	Files    int    // Number of files that would be copied.
+}
+
+// Candidates returns the packages that can be chosen in a conflict.
+// If the package is in the vendor folder of another project, the revision
+// is read from that project's vendor file.
+func (ctx *Context) Candidates(c *Conflict) ([]Candidate, error) {
+	var list []Candidate
+	for i, op := range c.Operation {
+		if op.State != OpReady {
+			continue
+		}
+		cand := Candidate{
+			OpIndex: i,
+			Local:   op.Pkg.Local,
+			Source:  conflictSource(op.Pkg.Local, c.Canonical),
+		}
+		system, err := vcs.FindVcs(op.Pkg.Gopath, op.Src)
+		if err != nil {
+			return nil, err
+		}
+		if system != nil {
+			cand.Dirty = system.Dirty
+			cand.Revision = system.Revision
+		}
+		if op.Pkg.inVendor && path.Base(cand.Source) == ctx.VendorFolder {
+			vf, err := readVendorFile(cand.Source+"/", filepath.Join(op.Pkg.Gopath, filepath.FromSlash(cand.Source), vendorFilename))
+			if err == nil {
+				cand.Revision = ""
+				for _, vp := range vf.Package {
+					if vp.Path == c.Canonical {
+						cand.Revision = vp.Revision
+						break
+					}
+				}
+			}
+		}
+		files, err := ctx.packageFiles(op.Src, c.Canonical, op.Pkg.IncludeTree)
+		if err != nil {
+			return nil, err
+		}
+		cand.Files = len(files)
+		list = append(list, cand)
+	}
+	return list, nil
+}
+
+// ResolveSimilar resolves the unresolved conflicts in cc that have a
+// candidate from source, such as "github.com/a/b/vendor". It returns the
+// conflicts resolved.
+func ResolveSimilar(cc []*Conflict, source string) []*Conflict {
+	var resolved []*Conflict
+	for _, c := range cc {
+		if c.Resolved {
+			continue
+		}
+		for i, op := range c.Operation {
+			if op.State != OpReady {
+				continue
+			}
+			if conflictSource(op.Pkg.Local, c.Canonical) == source {
+				c.OpIndex = i
+				c.Resolved = true
+				resolved = append(resolved, c)
+				break
+			}
+		}
+	}
+	return resolved
+}
+
+// SimilarConflicts returns the unresolved conflicts in cc that
+// ResolveSimilar would resolve for source.
+func SimilarConflicts(cc []*Conflict, source string) []*Conflict {
+	var similar []*Conflict
+	for _, c := range cc {
+		if c.Resolved {
+			continue
+		}
+		for _, op := range c.Operation {
+			if op.State == OpReady && conflictSource(op.Pkg.Local, c.Canonical) == source {
+				similar = append(similar, c)
+				break
+			}
+		}
+	}
+	return similar
+}
+
+// SortConflicts sorts the conflicts by canonical path.
+func SortConflicts(cc []*Conflict) {
+	sort.Sort(conflictSort(cc))
+}
+
+type conflictSort []*Conflict
+
+func (s conflictSort) Len() int           { return len(s) }
+func (s conflictSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s conflictSort) Less(i, j int) bool { return s[i].Canonical < s[j].Canonical }
+
+// conflictSource returns the local path without the canonical path,
+// or an empty string if the local path is the canonical path.
+func conflictSource(local, canonical string) string {
+	if local == canonical {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(local, canonical), "/")
+}
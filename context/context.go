@@ -8,12 +8,14 @@
 package context
 
 import (
+	gocontext "context"
 	"fmt"
 	"io"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/kardianos/govendor/internal/pathos"
 	os "github.com/kardianos/govendor/internal/vos"
@@ -37,13 +39,32 @@ func dprintf(f string, v ...interface{}) {
 // Context represents the current project context.
 type Context struct {
 	Logger   io.Writer // Write to the verbose log.
+	Events   EventSink // Receives progress events, may be nil.
 	Insecure bool      // Allow insecure network operations
+	Auth     *Auth     // Credentials for remote repositories.
+	Retry    Retry     // Retry policy for network operations.
+
+	// Interrupt stops VCS commands and network operations when done, and
+	// Alter and Sync then return ErrInterrupted. A deadline on it limits
+	// the time of all operations. May be nil.
+	Interrupt gocontext.Context
+
+	// CommandTimeout limits the time of each VCS command, zero for no limit.
+	CommandTimeout time.Duration
+
+	// KeepGoing continues fetching other packages after a remote failure.
+	// The failures are returned together once all operations are done.
+	KeepGoing bool
+
+	// Unresolved lists the cgo, go:embed and asset references of the
+	// packages copied that did not match any file.
+	Unresolved []UnresolvedRef
 
 	GopathList []string // List of GOPATHs in environment. Includes "src" dir.
 	Goroot     string   // The path to the standard library.
 
 	RootDir        string // Full path to the project root.
-	RootGopath     string // The GOPATH the project is in.
+	RootGopath     string // The GOPATH the project is in, or the project root if outside GOPATH.
 	RootImportPath string // The import path to the project.
 
 	VendorFile       *vendorfile.File
@@ -68,11 +89,24 @@ type Context struct {
 	loaded, dirty  bool
 	rewriteImports bool
 
-	ignoreTag      []string // list of tags to ignore
-	excludePackage []string // list of package prefixes to exclude
+	ignoreTag       []string // list of tags to ignore
+	excludePackage  []string // list of package prefixes to exclude
+	platforms       []Platform
+	goVersion       string   // The toolchain "go env GOVERSION".
+	outsideGopath   bool     // The project root is not in any GOPATH.
+	cacheRoot       string   // Set if the project is outside GOPATH.
+	requireChecksum []string // list of checksum algorithms each package must have
+
+	manifest *vendorfile.Manifest // File hashes of each package, nil if not used.
 
 	statusCache []StatusItem
 	added       map[string]bool
+
+	vendorStamp fileStamp             // The vendor file when read.
+	dirStamp    map[string]fileStamp  // Folders read by loadPackage.
+	parsed      map[string]parsedFile // Parsed imports of each file.
+
+	stage *vendorTx // Staged vendor folder changes during Alter.
 }
 
 // Package maintains information pertaining to a package.
@@ -91,6 +125,10 @@ type Package struct {
 
 	ignoreFile []string
 
+	// platformExcluded are the files ignored because they can't build for
+	// any target platform.
+	platformExcluded []string
+
 	// used in resolveUnknown function. Not persisted.
 	referenced map[string]*Package
 }
@@ -152,6 +190,12 @@ func NewEnv() (Env, error) {
 // NewContextWD creates a new context. It looks for a root folder by finding
 // a vendor file.
 func NewContextWD(rt RootType) (*Context, error) {
+	return NewContextWDImport(rt, "")
+}
+
+// NewContextWDImport creates a new context like NewContextWD. If the root
+// folder is outside GOPATH, rootImport is used as its import path.
+func NewContextWDImport(rt RootType, rootImport string) (*Context, error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		return nil, err
@@ -194,7 +238,7 @@ func NewContextWD(rt RootType) (*Context, error) {
 		return nil, ErrOldVersion{`Use the "migrate" command to update.`}
 	}
 
-	return NewContextRoot(root)
+	return newContext(root, filepath.Join("vendor", vendorFilename), "vendor", rootImport, false)
 }
 
 // NewContextRoot creates a new context for the given root folder.
@@ -207,7 +251,14 @@ func NewContextRoot(root string) (*Context, error) {
 
 // NewContext creates new context from a given root folder and vendor file path.
 // The vendorFolder is where vendor packages should be placed.
+//
+// A root folder outside GOPATH uses the import path recorded in the vendor
+// file "rootPath" or the import comment of a package file in the root folder.
 func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports bool) (*Context, error) {
+	return newContext(root, vendorFilePathRel, vendorFolder, "", rewriteImports)
+}
+
+func newContext(root, vendorFilePathRel, vendorFolder, rootImport string, rewriteImports bool) (*Context, error) {
 	dprintf("CTX: %s\n", root)
 	var err error
 
@@ -224,9 +275,6 @@ func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports boo
 	goroot = filepath.Join(goroot, "src")
 
 	// Get the GOPATHs. Prepend the GOROOT to the list.
-	if len(all) == 0 {
-		return nil, ErrMissingGOPATH
-	}
 	gopathList := filepath.SplitList(all)
 	gopathGoroot := make([]string, 0, len(gopathList)+1)
 	gopathGoroot = append(gopathGoroot, goroot)
@@ -258,26 +306,76 @@ func NewContext(root, vendorFilePathRel, vendorFolder string, rewriteImports boo
 
 		RewriteRule: make(map[string]string, 3),
 
+		Retry: DefaultRetry,
+
 		rewriteImports: rewriteImports,
 	}
 
 	ctx.RootImportPath, ctx.RootGopath, err = ctx.findImportPath(root)
+	if err != nil {
+		if _, is := err.(ErrNotInGOPATH); !is {
+			return nil, err
+		}
+		err = ctx.setOutsideRoot(rootImport, gopathList)
+		if err != nil {
+			if len(all) == 0 {
+				return nil, ErrMissingGOPATH
+			}
+			return nil, err
+		}
+	}
+
+	ctx.Auth, err = NewAuth()
+	if err != nil {
+		return nil, err
+	}
+	ctx.goVersion = goVersion(env)
+	ctx.loadDistList(ctx.goVersion)
+
+	err = recoverTx(filepath.Join(ctx.RootDir, ctx.VendorFolder))
 	if err != nil {
 		return nil, err
 	}
+	err = ctx.loadVendorFile()
+	if err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
 
-	vf, err := readVendorFile(path.Join(ctx.RootImportPath, vendorFolder)+"/", vendorFilePath)
+// loadVendorFile reads the vendor file and the settings it contains.
+func (ctx *Context) loadVendorFile() error {
+	ctx.vendorStamp = statStamp(ctx.VendorFilePath)
+	vf, err := readVendorFile(path.Join(ctx.RootImportPath, ctx.VendorFolder)+"/", ctx.VendorFilePath)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			return nil, err
+			return err
 		}
 		vf = &vendorfile.File{}
 	}
 	ctx.VendorFile = vf
 
 	ctx.IgnoreBuildAndPackage(vf.Ignore)
-
-	return ctx, nil
+	err = checkPrune(vf)
+	if err != nil {
+		return err
+	}
+	ctx.platforms, err = ParsePlatforms(vf.Platforms)
+	if err != nil {
+		return err
+	}
+	ctx.requireChecksum, err = parseRequireChecksum(vf.RequireChecksum)
+	if err != nil {
+		return err
+	}
+	ctx.manifest = nil
+	if len(vf.Manifest) > 0 {
+		ctx.manifest, err = readManifest(ctx.manifestPath())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // IgnoreBuildAndPackage takes a space separated list of tags or package prefixes
@@ -434,3 +532,11 @@ func (ctx *Context) updatePackageReferences() {
 		}
 	}
 }
+
+// interrupted returns ErrInterrupted if the Interrupt is done.
+func (ctx *Context) interrupted() error {
+	if ctx.Interrupt == nil || ctx.Interrupt.Err() == nil {
+		return nil
+	}
+	return ErrInterrupted{ctx.Interrupt.Err()}
+}
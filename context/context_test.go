@@ -214,6 +214,7 @@ func TestDuplicatePackage(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "1wArEyRQSnOYA1LDiCNvZxF4sm8=",
+			"checksumSHA256": "dSDn94l8P8bYWwFKEsp41oyCO5g9ctwlz612h8yb32k=",
 			"path": "co3/pk3",
 			"revision": ""
 		}
@@ -250,11 +251,13 @@ func TestDuplicatePackage(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "KrGLRMVV0FyxFX0FI4NavEDVJlY=",
+			"checksumSHA256": "ZWoTBGbja0l0UeINn5JP04Sw4OgOJ6U75wIjH4bhfyI=",
 			"path": "co2/pk2",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "1wArEyRQSnOYA1LDiCNvZxF4sm8=",
+			"checksumSHA256": "dSDn94l8P8bYWwFKEsp41oyCO5g9ctwlz612h8yb32k=",
 			"origin": "co1/vendor/co3/pk3",
 			"path": "co3/pk3",
 			"revision": ""
@@ -359,6 +362,7 @@ func TestImportSimple(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"path": "co2/pk1",
 			"revision": ""
 		}
@@ -424,6 +428,7 @@ func TestNoDep(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "QkjrJA3p/33sLeZnPIazB4vv30o=",
+			"checksumSHA256": "fd/0cjRIn95CbNFlMX4kWe8vxD1SRMT0jiUBPrv9ULw=",
 			"path": "co2/pk1",
 			"revision": ""
 		}
@@ -514,11 +519,13 @@ func TestUpdate(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"path": "co2/pk1",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "n1nb7gB6rHnnWwN+27InTig/ePo=",
+			"checksumSHA256": "zeVfx6bXzio7ckNRNw9a8OK1p+XxUXQJA1KsL/4xZ3U=",
 			"path": "co2/pk1/pk2",
 			"revision": ""
 		}
@@ -563,11 +570,13 @@ func TestUpdate(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"path": "co2/pk1",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "0GMhcCYB/xH0CWDPYljKj3W7ylY=",
+			"checksumSHA256": "eTPqytK6ba7IQ3x3sxgZcmiyqyGpPpcqpvVe4CymYn4=",
 			"path": "co2/pk1/pk2",
 			"revision": ""
 		}
@@ -596,6 +605,7 @@ func TestUpdate(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "0GMhcCYB/xH0CWDPYljKj3W7ylY=",
+			"checksumSHA256": "eTPqytK6ba7IQ3x3sxgZcmiyqyGpPpcqpvVe4CymYn4=",
 			"path": "co2/pk1/pk2",
 			"revision": ""
 		}
@@ -650,12 +660,14 @@ func TestVendor(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "auzf5l1iVWjiCTOwR9TuaFF2Db8=",
+			"checksumSHA256": "eLVIG85wLNeXmtYZcneliCXArUzfBvV09uxi5sZUN1I=",
 			"origin": "co2/vendor/a",
 			"path": "a",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "Ejt2NhWYzgcLKV1gpBW3Py9aF5w=",
+			"checksumSHA256": "rXofjQ0W4X6uRRifnpBv8PbHgMWJ0djSpm5sxTKtYDg=",
 			"path": "co2/pk1",
 			"revision": ""
 		}
@@ -814,12 +826,14 @@ func TestVendorFile(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "auzf5l1iVWjiCTOwR9TuaFF2Db8=",
+			"checksumSHA256": "eLVIG85wLNeXmtYZcneliCXArUzfBvV09uxi5sZUN1I=",
 			"origin": "co2/vendor/a",
 			"path": "a",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "Ejt2NhWYzgcLKV1gpBW3Py9aF5w=",
+			"checksumSHA256": "rXofjQ0W4X6uRRifnpBv8PbHgMWJ0djSpm5sxTKtYDg=",
 			"path": "co2/pk1",
 			"revision": ""
 		}
@@ -853,17 +867,20 @@ func TestVendorFile(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "auzf5l1iVWjiCTOwR9TuaFF2Db8=",
+			"checksumSHA256": "eLVIG85wLNeXmtYZcneliCXArUzfBvV09uxi5sZUN1I=",
 			"origin": "co2/vendor/a",
 			"path": "a",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "Ejt2NhWYzgcLKV1gpBW3Py9aF5w=",
+			"checksumSHA256": "rXofjQ0W4X6uRRifnpBv8PbHgMWJ0djSpm5sxTKtYDg=",
 			"path": "co2/pk1",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "Y7kuSBw+31U5RgCTp4XAMsWwr5Y=",
+			"checksumSHA256": "1M+KTdgX0BWOhoNHqyCP3n4rsKQ43rCMywJxGjO75jI=",
 			"path": "co3/pk1",
 			"revision": ""
 		}
@@ -1179,6 +1196,7 @@ func TestTree(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "2pIxVvLJ4iUMSmTWHwnAINQwI6A=",
+			"checksumSHA256": "2mSP2uJnj8tzpiAdLlaX8bfzPr6xdjsUFTpfKHrGWUI=",
 			"path": "co2/pk1",
 			"revision": "",
 			"tree": true
@@ -1342,12 +1360,14 @@ func TestOriginDir(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"origin": "co3/vendor/co2/pk1",
 			"path": "co2/pk1",
 			"revision": ""
 		},
 		{
 			"checksumSHA1": "9lQcNSYn9fe09txkREelZh/RSyw=",
+			"checksumSHA256": "32+stFkitlteX+F000vNnqHtxrHvojuGoHrJFJGiYxM=",
 			"origin": "co3/vendor/co2/pk1/sub1",
 			"path": "co2/pk1/sub1",
 			"revision": ""
@@ -1482,6 +1502,7 @@ func TestOrginRetain(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "KcwRyEXPUn2jwAgWGhFDjIt3deI=",
+			"checksumSHA256": "mqOvqrhVH7ZSzqTrivqyMvMYpLwecb04Vhy9JOdysEQ=",
 			"origin": "co2/pk2",
 			"path": "correct/name/pk2",
 			"revision": ""
@@ -1502,6 +1523,7 @@ func TestOrginRetain(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "KcwRyEXPUn2jwAgWGhFDjIt3deI=",
+			"checksumSHA256": "mqOvqrhVH7ZSzqTrivqyMvMYpLwecb04Vhy9JOdysEQ=",
 			"origin": "co2/pk2",
 			"path": "correct/name/pk2",
 			"revision": ""
@@ -6,8 +6,8 @@ package context
 
 import (
 	"fmt"
-	"hash"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -37,7 +37,40 @@ func (l fileInfoSort) Swap(i, j int) {
 
 // CopyPackage copies the files from the srcPath to the destPath, destPath
 // folder and parents are are created if they don't already exist.
-func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ignoreFiles []string, tree bool, h hash.Hash, beforeCopy func(deps []string) error) error {
+// The vendor file prune and ignore rules of pkgPath are applied.
+func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ignoreFiles []string, tree bool, h io.Writer, beforeCopy func(deps []string) error) error {
+	rules := ctx.pruneRules(pkgPath)
+	if rules.ownTags || len(ctx.platforms) > 0 {
+		var err error
+		ignoreFiles, _, err = ctx.getRulesIgnoreFiles(srcPath, "", rules)
+		if err != nil {
+			return err
+		}
+	}
+	rules.copied = make(map[string]bool)
+	err := ctx.copyPackage(destPath, srcPath, lookRoot, pkgPath, "", rules, ignoreFiles, tree, h, beforeCopy)
+	if err != nil {
+		return err
+	}
+	vp := ctx.VendorFilePackagePath(pkgPath)
+
+	// Copy the files the package references that were not copied with it.
+	extra, unresolved := resolveRefs(srcPath, lookRoot, pkgPath, append(rules.refs, assetRefs(vp)...), rules.copied)
+	ctx.Unresolved = append(ctx.Unresolved, unresolved...)
+	err = copyExtraFiles(destPath, srcPath, pkgPath, extra, h)
+	if err != nil {
+		return err
+	}
+	if vp != nil {
+		sort.Strings(rules.excluded)
+		vp.PlatformExcluded = strings.Join(rules.excluded, " ")
+		vp.ExtraFiles = strings.Join(extra, " ")
+	}
+	return nil
+}
+
+// copyPackage copies the folder rel of a package.
+func (ctx *Context) copyPackage(destPath, srcPath, lookRoot, pkgPath, rel string, rules *pruneRules, ignoreFiles []string, tree bool, h io.Writer, beforeCopy func(deps []string) error) error {
 	if pathos.FileStringEquals(destPath, srcPath) {
 		return fmt.Errorf("Attempting to copy package to same location %q.", destPath)
 	}
@@ -51,14 +84,6 @@ func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ign
 	if err != nil {
 		return err
 	}
-	ignoreTest := false
-	for _, ignore := range ctx.ignoreTag {
-		if ignore == "test" {
-			ignoreTest = true
-			break
-		}
-	}
-
 	fl, err := destDir.Readdir(-1)
 	destDir.Close()
 	if err != nil {
@@ -97,30 +122,19 @@ func (ctx *Context) CopyPackage(destPath, srcPath, lookRoot, pkgPath string, ign
 		// Sort file list to present a stable hash.
 		sort.Sort(fileInfoSort(fl))
 	}
-fileLoop:
 	for _, fi := range fl {
 		name := fi.Name()
-		if name[0] == '.' {
-			continue
-		}
 		if fi.IsDir() {
-			isTestdata := name == "testdata"
-			if !tree && !isTestdata {
+			nextRel := path.Join(rel, name)
+			if rules.skipDir(nextRel, tree) {
 				continue
 			}
-			if name[0] == '_' {
-				continue
-			}
-			if ignoreTest {
-				if strings.HasSuffix(name, "_test") || isTestdata {
-					continue
-				}
-			}
+			isTestdata := name == "testdata"
 			nextDestPath := filepath.Join(destPath, name)
 			nextSrcPath := filepath.Join(srcPath, name)
 			var nextIgnoreFiles, deps []string
 			if !isTestdata && !strings.Contains(pkgPath, "/testdata/") {
-				nextIgnoreFiles, deps, err = ctx.getIgnoreFiles(nextSrcPath)
+				nextIgnoreFiles, deps, err = ctx.getRulesIgnoreFiles(nextSrcPath, nextRel, rules)
 				if err != nil {
 					return err
 				}
@@ -131,7 +145,7 @@ fileLoop:
 					return errors.Wrap(err, "beforeCopy")
 				}
 			}
-			err = ctx.CopyPackage(nextDestPath, nextSrcPath, lookRoot, path.Join(pkgPath, name), nextIgnoreFiles, true, h, beforeCopy)
+			err = ctx.copyPackage(nextDestPath, nextSrcPath, lookRoot, path.Join(pkgPath, name), nextRel, rules, nextIgnoreFiles, true, h, beforeCopy)
 			if err != nil {
 				return errors.Wrapf(err,
 					"CopyPackage dest=%q src=%q lookRoot=%q pkgPath=%q ignoreFiles=%q tree=%t has beforeCopy=%t",
@@ -140,28 +154,89 @@ fileLoop:
 			}
 			continue
 		}
-		for _, ignore := range ignoreFiles {
-			if pathos.FileStringEquals(name, ignore) {
-				continue fileLoop
+		fileRel := path.Join(rel, name)
+		if rules.skipFile(fileRel, ignoreFiles) {
+			continue
+		}
+		rules.copied[fileRel] = true
+		if strings.HasSuffix(name, ".go") && !strings.Contains("/"+rel+"/", "/testdata/") {
+			refs, err := fileRefs(srcPath, rel, name)
+			if err != nil {
+				return err
 			}
+			rules.refs = append(rules.refs, refs...)
 		}
+		fh, _ := h.(fileHasher)
 		if h != nil {
 			h.Write([]byte(name))
 		}
+		if fh != nil {
+			fh.beginFile(path.Join(strings.Trim(pkgPath, "/"), name))
+		}
 		err = copyFile(
 			filepath.Join(destPath, name),
 			filepath.Join(srcPath, name),
 			h,
 		)
+		if fh != nil {
+			fh.endFile()
+		}
 		if err != nil {
 			return errors.Wrapf(err, "copyFile dest=%q src=%q", filepath.Join(destPath, name), filepath.Join(srcPath, name))
 		}
 	}
 
-	return errors.Wrapf(licenseCopy(lookRoot, srcPath, filepath.Join(ctx.RootDir, ctx.VendorFolder), pkgPath), "licenseCopy srcPath=%q", srcPath)
+	vendorRoot := ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder))
+	return errors.Wrapf(licenseCopy(lookRoot, srcPath, vendorRoot, pkgPath), "licenseCopy srcPath=%q", srcPath)
+}
+
+// packageFiles returns the files CopyPackage would copy from srcPath into
+// the package pkgPath as slash separated paths relative to srcPath.
+func (ctx *Context) packageFiles(srcPath, pkgPath string, tree bool) ([]string, error) {
+	rules := ctx.pruneRules(pkgPath)
+	ignoreFiles, _, err := ctx.getRulesIgnoreFiles(srcPath, "", rules)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.rulesPackageFiles(srcPath, pkgPath, "", rules, ignoreFiles, tree)
+}
+
+func (ctx *Context) rulesPackageFiles(srcPath, pkgPath, rel string, rules *pruneRules, ignoreFiles []string, tree bool) ([]string, error) {
+	fl, err := ioutil.ReadDir(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, fi := range fl {
+		name := fi.Name()
+		nextRel := path.Join(rel, name)
+		if !fi.IsDir() {
+			if !rules.skipFile(nextRel, ignoreFiles) {
+				list = append(list, nextRel)
+			}
+			continue
+		}
+		if rules.skipDir(nextRel, tree) {
+			continue
+		}
+		nextSrcPath := filepath.Join(srcPath, name)
+		var nextIgnoreFiles []string
+		if name != "testdata" && !strings.Contains(pkgPath, "/testdata/") {
+			nextIgnoreFiles, _, err = ctx.getRulesIgnoreFiles(nextSrcPath, nextRel, rules)
+			if err != nil {
+				return nil, err
+			}
+		}
+		sub, err := ctx.rulesPackageFiles(nextSrcPath, path.Join(pkgPath, name), nextRel, rules, nextIgnoreFiles, true)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sub...)
+	}
+	return list, nil
 }
 
-func copyFile(destPath, srcPath string, h hash.Hash) error {
+func copyFile(destPath, srcPath string, h io.Writer) error {
 	ss, err := os.Stat(srcPath)
 	if err != nil {
 		return errors.Wrap(err, "copyFile Stat")
@@ -178,6 +253,12 @@ func copyFile(destPath, srcPath string, h hash.Hash) error {
 		}
 	}
 
+	// Remove any existing file rather than truncate it, it may be a hard
+	// link to a file in the vendor folder. See vendorTx.
+	err = os.Remove(destPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "remove dest=%q", destPath)
+	}
 	dest, err := os.Create(destPath)
 	if err != nil {
 		return errors.Wrapf(err, "create dest=%q", destPath)
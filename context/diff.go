@@ -0,0 +1,243 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/diff"
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/pkgspec"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// DiffSource is what a vendor package is compared to.
+type DiffSource byte
+
+const (
+	DiffGopath   DiffSource = iota // The package in GOPATH.
+	DiffRevision                   // The cached repo at the vendor file revision.
+	DiffVersion                    // The cached repo at the package spec version.
+)
+
+// Diff writes a unified diff of the vendor package and the source.
+// For DiffGopath and DiffVersion the diff shows how update or fetch would
+// change the vendor package. For DiffRevision the diff shows the local
+// changes made to the vendor package. Only files that would be copied to
+// the vendor folder are compared.
+func (ctx *Context) Diff(w io.Writer, ps *pkgspec.Pkg, source DiffSource) error {
+	vp := ctx.VendorFilePackagePath(ps.Path)
+	if vp == nil {
+		return fmt.Errorf("Package %q is not in the vendor file", ps.Path)
+	}
+	from := vp.PathOrigin()
+	if ps.HasOrigin {
+		from = ps.Origin
+	}
+	tree := vp.Tree || ps.IncludeTree
+
+	var srcDir, srcLabel string
+	switch source {
+	default:
+		return fmt.Errorf("Unknown diff source %d", source)
+	case DiffGopath:
+		dir, _, err := ctx.findImportDir("", from)
+		if err != nil {
+			return err
+		}
+		srcDir, srcLabel = dir, from
+	case DiffRevision, DiffVersion:
+		version := vp.Revision
+		if source == DiffVersion {
+			if !ps.HasVersion || len(ps.Version) == 0 {
+				return fmt.Errorf("Missing version to compare %q to", ps.Path)
+			}
+			version = ps.Version
+		}
+		if len(version) == 0 {
+			return fmt.Errorf("Package %q has no revision in the vendor file", ps.Path)
+		}
+		dir, err := ctx.cacheCheckout(from, version)
+		if err != nil {
+			return err
+		}
+		srcDir, srcLabel = dir, from+"@"+version
+	}
+
+	srcFiles, err := ctx.packageFiles(srcDir, vp.Path, tree)
+	if err != nil {
+		return err
+	}
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path))
+	vendorFiles, err := ctx.packageFiles(vendorDir, vp.Path, tree)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	srcFiles = withExtraFiles(srcDir, srcFiles, vp)
+	vendorFiles = withExtraFiles(vendorDir, vendorFiles, vp)
+	vendorLabel := path.Join(filepath.ToSlash(ctx.VendorFolder), vp.Path)
+
+	if source == DiffRevision {
+		return writeDirDiff(w, srcDir, srcLabel, srcFiles, vendorDir, vendorLabel, vendorFiles)
+	}
+	return writeDirDiff(w, vendorDir, vendorLabel, vendorFiles, srcDir, srcLabel, srcFiles)
+}
+
+// cacheCheckout updates the cached repo of the package from to the version
+// or revision and returns the package folder.
+func (ctx *Context) cacheCheckout(from, version string) (string, error) {
+	cacheRoot := ctx.CacheRoot()
+	err := os.MkdirAll(cacheRoot, 0700)
+	if err != nil {
+		return "", err
+	}
+	revision := version
+	if isVersion(version) {
+		revision = ""
+	}
+	vcsCmd, repoRootDir, err := ctx.openCacheRepo(cacheRoot, from, revision)
+	if err != nil {
+		return "", err
+	}
+	if len(revision) > 0 {
+		err = ctx.cacheRevisionSync(vcsCmd, repoRootDir, revision)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(cacheRoot, pathos.SlashToFilepath(from)), nil
+	}
+
+	err = ctx.retry("download "+from, func() error {
+		err := vcsCmd.Download(repoRootDir)
+		if err != nil {
+			return err
+		}
+		// Tags are not present in a shallow repo.
+		return vcsCmd.Deepen(repoRootDir)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download repo into %q %v", repoRootDir, err)
+	}
+	touchCacheRepo(repoRootDir)
+	tagNames, err := vcsCmd.Tags(repoRootDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch tags %v", err)
+	}
+	labels := make([]Label, len(tagNames))
+	for i, tag := range tagNames {
+		labels[i].Source = LabelTag
+		labels[i].Text = tag
+	}
+	result := FindLabel(version, labels)
+	if result.Source == LabelNone {
+		return "", fmt.Errorf("No label found for specified version %q from %s", version, from)
+	}
+	err = vcsCmd.TagSync(repoRootDir, result.Text)
+	if err != nil {
+		return "", fmt.Errorf("failed to sync repo to tag %q %v", result.Text, err)
+	}
+	return filepath.Join(cacheRoot, pathos.SlashToFilepath(from)), nil
+}
+
+// withExtraFiles adds the extra files of the vendor package that are in
+// dir to files.
+func withExtraFiles(dir string, files []string, vp *vendorfile.Package) []string {
+	has := make(map[string]bool, len(files))
+	for _, name := range files {
+		has[name] = true
+	}
+	for _, rel := range strings.Fields(vp.ExtraFiles) {
+		if has[rel] {
+			continue
+		}
+		if fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); err == nil && fi.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+	}
+	return files
+}
+
+// vendorPackageFiles returns the files of the vendor package folder as
+// slash separated paths relative to dir, the same files as the checksum.
+func vendorPackageFiles(dir string, tree bool) ([]string, error) {
+	var list []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == dir && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			if p != dir && !tree {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		list = append(list, filepath.ToSlash(rel))
+		return nil
+	})
+	return list, err
+}
+
+// writeDirDiff writes the unified diff of each file from the a folder to
+// the b folder. A file only in one folder is compared to an empty file.
+func writeDirDiff(w io.Writer, aDir, aLabel string, aFiles []string, bDir, bLabel string, bFiles []string) error {
+	inA := make(map[string]bool, len(aFiles))
+	names := make([]string, 0, len(aFiles)+len(bFiles))
+	for _, name := range aFiles {
+		inA[name] = true
+		names = append(names, name)
+	}
+	for _, name := range bFiles {
+		if !inA[name] {
+			names = append(names, name)
+		}
+	}
+	inB := make(map[string]bool, len(bFiles))
+	for _, name := range bFiles {
+		inB[name] = true
+	}
+	sort.Strings(names)
+
+	read := func(dir, name string, has bool) ([]byte, error) {
+		if !has {
+			return nil, nil
+		}
+		return ioutil.ReadFile(filepath.Join(dir, pathos.SlashToFilepath(name)))
+	}
+	label := func(prefix, name string, has bool) string {
+		if !has {
+			return "/dev/null"
+		}
+		return prefix + "/" + name
+	}
+	for _, name := range names {
+		a, err := read(aDir, name, inA[name])
+		if err != nil {
+			return err
+		}
+		b, err := read(bDir, name, inB[name])
+		if err != nil {
+			return err
+		}
+		err = diff.Unified(w, label(aLabel, name, inA[name]), label(bLabel, name, inB[name]), a, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
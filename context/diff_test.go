@@ -0,0 +1,128 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestDiffGopath(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	src := g.Path("co2/pk1")
+	writeFile(t, filepath.Join(src, "a.go"), "package pk1\n\nconst A = 1\n")
+
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), AddUpdate))
+	g.Check(c.Alter())
+
+	writeFile(t, filepath.Join(src, "a.go"), "package pk1\n\nconst A = 2\n")
+	writeFile(t, filepath.Join(src, "b.go"), "package pk1\n")
+	writeFile(t, filepath.Join(src, ".b.go"), "not copied\n")
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Diff(buf, pkg("co2/pk1"), DiffGopath))
+	want := "--- vendor/co2/pk1/a.go\n" +
+		"+++ co2/pk1/a.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" package pk1\n" +
+		" \n" +
+		"-const A = 1\n" +
+		"+const A = 2\n" +
+		"--- /dev/null\n" +
+		"+++ co2/pk1/b.go\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+package pk1\n"
+	if buf.String() != want {
+		t.Fatalf("got\n%s", buf.String())
+	}
+}
+
+func TestDiffCopied(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	src := g.Path("co2/pk1")
+	writeFile(t, filepath.Join(src, "b.go"), "package pk1\n\nimport _ \"embed\"\n\n//go:embed static/x.txt\nvar x string\n")
+	g.Check(os.MkdirAll(filepath.Join(src, "static"), 0777))
+	writeFile(t, filepath.Join(src, "static", "x.txt"), "x\n")
+	g.Check(os.MkdirAll(filepath.Join(src, "testdata"), 0777))
+	writeFile(t, filepath.Join(src, "testdata", "in.txt"), "in\n")
+
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), AddUpdate))
+	g.Check(c.Alter())
+	if c.VendorFilePackagePath("co2/pk1").ExtraFiles != "static/x.txt" {
+		t.Fatalf("expected extra file, got %q", c.VendorFilePackagePath("co2/pk1").ExtraFiles)
+	}
+
+	// A package just copied has no differences.
+	buf := &bytes.Buffer{}
+	g.Check(c.Diff(buf, pkg("co2/pk1"), DiffGopath))
+	if buf.Len() != 0 {
+		t.Fatalf("got\n%s", buf.String())
+	}
+}
+
+func TestDiffRevision(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	writeFile(t, filepath.Join(g.Path("remote/co2/pk1"), "a.go"), "package pk1\n")
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	remote.Setup().Commit()
+
+	g.In("co1")
+	c := ctx(g)
+	remotePkg := remote.HttpAddr() + "/remote/co2/pk1"
+	g.Check(c.ModifyImport(pkg(remotePkg), Fetch))
+	g.Check(c.Alter())
+
+	// A local fix to the vendor package.
+	vendorPkg := filepath.Join(g.Current(), "vendor", filepath.FromSlash(remotePkg))
+	writeFile(t, filepath.Join(vendorPkg, "a.go"), "package pk1 // fixed\n")
+
+	buf := &bytes.Buffer{}
+	g.Check(c.Diff(buf, pkg(remotePkg), DiffRevision))
+	rev := c.VendorFilePackagePath(remotePkg).Revision
+	want := `--- ` + remotePkg + `@` + rev + `/a.go
++++ vendor/` + remotePkg + `/a.go
+@@ -1 +1 @@
+-package pk1
++package pk1 // fixed
+`
+	if buf.String() != want {
+		t.Fatalf("got\n%s", buf.String())
+	}
+}
@@ -5,6 +5,8 @@
 package context
 
 import (
+	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 )
@@ -25,6 +27,16 @@ func (err ErrNotInGOPATH) Error() string {
 	return fmt.Sprintf("Package %q not a go package or not in GOPATH.", err.Missing)
 }
 
+// ErrNoRootImport returns if the project root is outside GOPATH and
+// the import path of the project is unknown.
+type ErrNoRootImport struct {
+	Dir string
+}
+
+func (err ErrNoRootImport) Error() string {
+	return fmt.Sprintf("Folder %q is not in GOPATH. Set the project import path with -root-import, the vendor.json \"rootPath\" or an import comment.", err.Dir)
+}
+
 // ErrDirtyPackage returns if package is in dirty version control.
 type ErrDirtyPackage struct {
 	ImportPath string
@@ -34,6 +46,30 @@ func (err ErrDirtyPackage) Error() string {
 	return fmt.Sprintf("Package %q has uncommitted changes in the vcs.", err.ImportPath)
 }
 
+// ErrInterrupted returns if the Context Interrupt is done before an
+// operation completes.
+type ErrInterrupted struct {
+	Err error // The Interrupt error.
+}
+
+func (err ErrInterrupted) Error() string {
+	if err.Err == gocontext.DeadlineExceeded {
+		return "Stopped at the timeout."
+	}
+	return "Interrupted."
+}
+
+// ErrPatchConflict returns if a package patch does not apply.
+type ErrPatchConflict struct {
+	ImportPath string
+	Patch      string
+	Err        error
+}
+
+func (err ErrPatchConflict) Error() string {
+	return fmt.Sprintf("Patch %q for package %q does not apply: %v", err.Patch, err.ImportPath, err.Err)
+}
+
 // ErrPackageExists returns if package already exists.
 type ErrPackageExists struct {
 	Package string
@@ -78,3 +114,32 @@ type ErrTreeParents struct {
 func (err ErrTreeParents) Error() string {
 	return fmt.Sprintf("Cannot add package %q which is already found in sub-tree %q", err.path, err.parents)
 }
+
+// RemoteFailure is a failed remote operation for a single package.
+type RemoteFailure struct {
+	Path string // Import path of the package.
+	Msg  string // The operation that failed.
+	Err  error
+}
+
+func (fail RemoteFailure) Error() string {
+	return fmt.Sprintf("Failed for %q (%s): %v", fail.Path, fail.Msg, fail.Err)
+}
+
+// ErrRemoteFailures returns if some remote operations failed while the
+// remaining operations were completed.
+type ErrRemoteFailures []RemoteFailure
+
+func (list ErrRemoteFailures) Error() string {
+	if len(list) == 0 {
+		return "(no remote failure)"
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Remotes failed for %d package(s):\n", len(list))
+	for _, item := range list {
+		buf.WriteString("\t")
+		buf.WriteString(item.Error())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
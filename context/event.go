@@ -0,0 +1,141 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io"
+	"time"
+)
+
+// EventKind is the step of an operation an Event reports.
+type EventKind byte
+
+const (
+	EventResolve  EventKind = iota + 1 // EventResolve finds the repo of an import path.
+	EventClone                         // EventClone creates a repo in the cache.
+	EventDownload                      // EventDownload updates a repo in the cache.
+	EventTag                           // EventTag finds the tag that matches a version.
+	EventCopy                          // EventCopy copies a package into the vendor folder.
+	EventRemove                        // EventRemove removes a package from the vendor folder.
+	EventChecksum                      // EventChecksum computes the checksum of a vendor package.
+	EventRewrite                       // EventRewrite rewrites the imports of a file.
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventResolve:
+		return "resolve"
+	case EventClone:
+		return "clone"
+	case EventDownload:
+		return "download"
+	case EventTag:
+		return "tag"
+	case EventCopy:
+		return "copy"
+	case EventRemove:
+		return "remove"
+	case EventChecksum:
+		return "checksum"
+	case EventRewrite:
+		return "rewrite"
+	}
+	return "unknown"
+}
+
+// Event reports the start or finish of a step. Each step sends an event
+// when it starts and another with Done set when it finishes.
+type Event struct {
+	Kind EventKind
+	Done bool
+
+	Package  string // Import path of the package.
+	Repo     string // Repo URL, set when known.
+	Path     string // Folder or file worked on.
+	Revision string // Revision or tag, set when known.
+
+	// Set when Done.
+	Elapsed time.Duration
+	Bytes   int64 // Bytes cloned, downloaded, copied, removed, hashed or written.
+	Files   int   // Files copied or hashed, if counted.
+	Err     error
+}
+
+// EventSink receives the events of a Context. Events are sent from the
+// goroutine that calls the Context method, in the order they happen.
+type EventSink interface {
+	Event(e Event)
+}
+
+// EventFunc is an EventSink function.
+type EventFunc func(e Event)
+
+// Event calls f(e).
+func (f EventFunc) Event(e Event) {
+	f(e)
+}
+
+// eventSpan is a started step. Fields of the event may be set before
+// the finish event is sent.
+type eventSpan struct {
+	Event
+	ctx   *Context
+	start time.Time
+}
+
+func (ctx *Context) emit(e Event) {
+	if ctx.Events == nil {
+		return
+	}
+	if len(e.Repo) > 0 {
+		e.Repo = redactArgs([]string{e.Repo})[0]
+	}
+	ctx.Events.Event(e)
+}
+
+// startEvent sends the start event and returns the span to finish.
+func (ctx *Context) startEvent(e Event) *eventSpan {
+	ctx.emit(e)
+	return &eventSpan{Event: e, ctx: ctx, start: time.Now()}
+}
+
+// finish sends the finish event.
+func (s *eventSpan) finish(bytes int64, err error) {
+	s.Done = true
+	s.Elapsed = time.Since(s.start)
+	s.Bytes = bytes
+	s.Err = err
+	s.ctx.emit(s.Event)
+}
+
+// folderSize returns the size of the files in dir if events are sent.
+// Walking the folder is skipped otherwise.
+func (ctx *Context) folderSize(dir string) int64 {
+	if ctx.Events == nil {
+		return 0
+	}
+	size, _ := dirSize(dir)
+	return size
+}
+
+// grown returns the bytes a folder grew by.
+func grown(before, after int64) int64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
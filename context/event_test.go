@@ -0,0 +1,70 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestEvents(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go"),
+	)
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	repo := remote.Setup()
+	rev, _ := repo.Commit()
+
+	g.In("co1")
+	c := ctx(g)
+	var events []Event
+	c.Events = EventFunc(func(e Event) {
+		events = append(events, e)
+	})
+	remotePkg := remote.HttpAddr() + "/remote/co2/pk1"
+	g.Check(c.ModifyImport(pkg(remotePkg+"@"+rev), Fetch))
+	g.Check(c.Alter())
+	g.Check(c.ModifyImport(pkg(remotePkg), Remove))
+	g.Check(c.Alter())
+
+	buf := &bytes.Buffer{}
+	for i, e := range events {
+		if e.Done && (e.Elapsed <= 0 || e.Err != nil) {
+			t.Errorf("event %d %v: elapsed %v, error %v", i, e.Kind, e.Elapsed, e.Err)
+		}
+		switch {
+		case !e.Done:
+			fmt.Fprintf(buf, "start %s %s\n", e.Kind, e.Package)
+		case e.Kind == EventCopy:
+			fmt.Fprintf(buf, "done %s files=%d bytes=%t\n", e.Kind, e.Files, e.Bytes > 0)
+		default:
+			fmt.Fprintf(buf, "done %s bytes=%t\n", e.Kind, e.Bytes > 0)
+		}
+	}
+	expected := fmt.Sprintf(`start resolve %[1]s
+done resolve bytes=false
+start clone %[1]s
+done clone bytes=true
+start copy %[1]s
+done copy files=1 bytes=true
+start remove %[1]s
+done remove bytes=true
+`, remotePkg)
+	if buf.String() != expected {
+		t.Fatalf("Got\n%s", buf.String())
+	}
+}
@@ -27,8 +27,7 @@ type fetcher struct {
 }
 
 func newFetcher(ctx *Context) (*fetcher, error) {
-	// GOPATH here includes the "src" dir, go up one level.
-	cacheRoot := filepath.Join(ctx.RootGopath, "..", ".cache", "govendor")
+	cacheRoot := ctx.CacheRoot()
 	err := os.MkdirAll(cacheRoot, 0700)
 	if err != nil {
 		return nil, err
@@ -66,8 +65,20 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 		}
 	}
 
+	revision := ""
+	if ps.HasVersion {
+		switch {
+		case len(ps.Version) == 0:
+			vpkg.Version = ""
+		case isVersion(ps.Version):
+			vpkg.Version = ps.Version
+		default:
+			revision = ps.Version
+		}
+	}
+
 	// Don't check for bundle, rather check physical directory.
-	// If no repo in dir, clone.
+	// If no repo in dir, clone, only the revision if a specific one is wanted.
 	// If there is a repo in dir, update to latest.
 	// Get any tags.
 	// If we have a specific revision, update to that revision.
@@ -76,8 +87,11 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 	sysVcsCmd, repoRoot, err := vcs.FromDir(pkgDir, f.CacheRoot)
 	var vcsCmd *VCSCmd
 	repoRootDir := filepath.Join(f.CacheRoot, repoRoot)
+	if err == nil && removeAbandoned(repoRootDir) {
+		err = errAbandoned
+	}
 	if err != nil {
-		rr, err := vcs.RepoRootForImportPath(ps.PathOrigin(), false)
+		rr, err := f.Ctx.repoRoot(ps.PathOrigin())
 		if err != nil {
 			if strings.Contains(err.Error(), "unrecognized import path") {
 				return nextOps, nil
@@ -88,52 +102,54 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 			return nextOps, fmt.Errorf("repo remote not secure")
 		}
 
-		vcsCmd = updateVcsCmd(rr.VCS)
+		vcsCmd = f.Ctx.newVcsCmd(rr.VCS)
 		repoRoot = rr.Root
 		repoRootDir = filepath.Join(f.CacheRoot, repoRoot)
 
-		err = vcsCmd.Create(repoRootDir, rr.Repo)
+		err = f.Ctx.Auth.setup(vcsCmd, rr.Repo, repoRootDir)
+		if err != nil {
+			return nextOps, err
+		}
+		span := f.Ctx.startEvent(Event{Kind: EventClone, Package: ps.Path, Repo: rr.Repo, Path: repoRootDir, Revision: revision})
+		err = f.Ctx.retry("create "+rr.Repo, func() error {
+			if len(revision) > 0 {
+				return vcsCmd.CreateShallow(repoRootDir, rr.Repo, revision)
+			}
+			return vcsCmd.Create(repoRootDir, rr.Repo)
+		})
+		span.finish(f.Ctx.folderSize(repoRootDir), err)
 		if err != nil {
 			return nextOps, fmt.Errorf("failed to create repo %q in %q %v", rr.Repo, repoRootDir, err)
 		}
 
 	} else {
-		vcsCmd = updateVcsCmd(sysVcsCmd)
-		err = vcsCmd.Download(repoRootDir)
+		vcsCmd = f.Ctx.newVcsCmd(sysVcsCmd)
+		err = f.Ctx.Auth.setup(vcsCmd, "", repoRootDir)
 		if err != nil {
-			return nextOps, fmt.Errorf("failed to download repo into %q %v", repoRootDir, err)
+			return nextOps, err
 		}
-	}
-
-	revision := ""
-	if ps.HasVersion {
-		switch {
-		case len(ps.Version) == 0:
-			vpkg.Version = ""
-		case isVersion(ps.Version):
-			vpkg.Version = ps.Version
-		default:
-			revision = ps.Version
+		before := f.Ctx.folderSize(repoRootDir)
+		span := f.Ctx.startEvent(Event{Kind: EventDownload, Package: ps.Path, Path: repoRootDir, Revision: revision})
+		err = f.Ctx.retry("download "+repoRoot, func() error {
+			if len(revision) > 0 {
+				return vcsCmd.DownloadRevision(repoRootDir, revision)
+			}
+			return vcsCmd.Download(repoRootDir)
+		})
+		span.finish(grown(before, f.Ctx.folderSize(repoRootDir)), err)
+		if err != nil {
+			return nextOps, fmt.Errorf("failed to download repo into %q %v", repoRootDir, err)
 		}
 	}
+	touchCacheRepo(repoRootDir)
 
 	switch {
 	case len(revision) == 0 && len(vpkg.Version) > 0:
 		fmt.Fprintf(f.Ctx, "Get version %q@%s\n", vpkg.Path, vpkg.Version)
-		// Get a list of tags, match to version if possible.
-		var tagNames []string
-		tagNames, err = vcsCmd.Tags(repoRootDir)
+		var result Label
+		result, err = f.findTag(ps, vpkg.Version, vcsCmd, repoRootDir)
 		if err != nil {
-			return nextOps, fmt.Errorf("failed to fetch tags %v", err)
-		}
-		labels := make([]Label, len(tagNames))
-		for i, tag := range tagNames {
-			labels[i].Source = LabelTag
-			labels[i].Text = tag
-		}
-		result := FindLabel(vpkg.Version, labels)
-		if result.Source == LabelNone {
-			return nextOps, fmt.Errorf("No label found for specified version %q from %s", vpkg.Version, ps.String())
+			return nextOps, err
 		}
 		vpkg.VersionExact = result.Text
 		fmt.Fprintf(f.Ctx, "\tFound exact version %q\n", vpkg.VersionExact)
@@ -163,7 +179,7 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 	// /tmp/cache/1/[[github.com/kardianos/govendor]]context
 	op.Src = pkgDir
 	var deps []string
-	op.IgnoreFile, deps, err = f.Ctx.getIgnoreFiles(op.Src)
+	op.IgnoreFile, deps, err = f.Ctx.packageIgnoreFiles(op.Pkg.Path, op.Src)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nextOps, nil
@@ -275,6 +291,7 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 
 			// Update vendor file with correct Local field.
 			vp := f.Ctx.VendorFilePackagePath(dep)
+			before := savePackage(vp)
 			if vp == nil {
 				vp = &vendorfile.Package{
 					Add:      true,
@@ -302,10 +319,11 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 				HasOrigin:  hasOrigin,
 			}
 			nextOps = append(nextOps, &Operation{
-				Type: OpFetch,
-				Pkg:  &Package{Pkg: spec},
-				Src:  spec.String(),
-				Dest: dest,
+				Type:   OpFetch,
+				Pkg:    &Package{Pkg: spec},
+				Src:    spec.String(),
+				Dest:   dest,
+				before: before,
 			})
 		}
 		return nil
@@ -323,3 +341,36 @@ func (f *fetcher) op(op *Operation) ([]*Operation, error) {
 
 	return nextOps, nil
 }
+
+// findTag returns the tag of the cached repo that best matches version.
+func (f *fetcher) findTag(ps *pkgspec.Pkg, version string, vcsCmd *VCSCmd, repoRootDir string) (result Label, err error) {
+	before := f.Ctx.folderSize(repoRootDir)
+	span := f.Ctx.startEvent(Event{Kind: EventTag, Package: ps.Path, Path: repoRootDir})
+	defer func() {
+		span.Revision = result.Text
+		span.finish(grown(before, f.Ctx.folderSize(repoRootDir)), err)
+	}()
+
+	// Get a list of tags, match to version if possible.
+	// Tags are not present in a shallow repo.
+	err = f.Ctx.retry("deepen "+repoRootDir, func() error {
+		return vcsCmd.Deepen(repoRootDir)
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch history %v", err)
+	}
+	tagNames, err := vcsCmd.Tags(repoRootDir)
+	if err != nil {
+		return result, fmt.Errorf("failed to fetch tags %v", err)
+	}
+	labels := make([]Label, len(tagNames))
+	for i, tag := range tagNames {
+		labels[i].Source = LabelTag
+		labels[i].Text = tag
+	}
+	result = FindLabel(version, labels)
+	if result.Source == LabelNone {
+		return result, fmt.Errorf("No label found for specified version %q from %s", version, ps.String())
+	}
+	return result, nil
+}
@@ -32,12 +32,21 @@ func Get(logger io.Writer, pkgspecName string, insecure bool) (*pkgspec.Pkg, err
 }
 
 func get(logger io.Writer, gopath string, ps *pkgspec.Pkg, insecure bool) error {
+	auth, err := NewAuth()
+	if err != nil {
+		return err
+	}
 	pkgDir := filepath.Join(gopath, ps.Path)
 	sysVcsCmd, repoRoot, err := vcs.FromDir(pkgDir, gopath)
 	var vcsCmd *VCSCmd
 	repoRootDir := filepath.Join(gopath, repoRoot)
 	if err != nil {
-		rr, err := vcs.RepoRootForImportPath(ps.PathOrigin(), false)
+		var rr *vcs.RepoRoot
+		err = DefaultRetry.do(logger, "resolve "+ps.PathOrigin(), func() error {
+			var err error
+			rr, err = auth.repoRootForImportPath(ps.PathOrigin())
+			return err
+		})
 		if err != nil {
 			return err
 		}
@@ -49,14 +58,26 @@ func get(logger io.Writer, gopath string, ps *pkgspec.Pkg, insecure bool) error
 		repoRoot = rr.Root
 		repoRootDir = filepath.Join(gopath, repoRoot)
 
-		err = vcsCmd.Create(repoRootDir, rr.Repo)
+		err = auth.setup(vcsCmd, rr.Repo, repoRootDir)
+		if err != nil {
+			return err
+		}
+		err = DefaultRetry.do(logger, "create "+rr.Repo, func() error {
+			return vcsCmd.Create(repoRootDir, rr.Repo)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to create repo %q in %q %v", rr.Repo, repoRootDir, err)
 		}
 
 	} else {
 		vcsCmd = updateVcsCmd(sysVcsCmd)
-		err = vcsCmd.Download(repoRootDir)
+		err = auth.setup(vcsCmd, "", repoRootDir)
+		if err != nil {
+			return err
+		}
+		err = DefaultRetry.do(logger, "download "+repoRoot, func() error {
+			return vcsCmd.Download(repoRootDir)
+		})
 		if err != nil {
 			return fmt.Errorf("failed to download repo into %q %v", repoRootDir, err)
 		}
@@ -86,6 +107,5 @@ func get(logger io.Writer, gopath string, ps *pkgspec.Pkg, insecure bool) error
 			added[item.Pkg.Path] = true
 		}
 	}
-	defer ctx.WriteVendorFile()
 	return ctx.Alter()
 }
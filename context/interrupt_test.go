@@ -0,0 +1,102 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"golang.org/x/tools/go/vcs"
+)
+
+func TestInterrupt(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	interrupt, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+
+	// Retries stop without waiting for the delay.
+	calls := 0
+	r := Retry{Attempts: 3, Delay: time.Hour}
+	err := r.doInterrupt(interrupt, nil, "fail", func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if _, is := err.(ErrInterrupted); !is || calls != 1 {
+		t.Fatalf("retry: got %d calls, error %v", calls, err)
+	}
+
+	// A create that is stopped leaves nothing behind.
+	dir := g.Path("cache/repo")
+	vcsCmd := updateVcsCmd(vcs.ByCmd("git"))
+	vcsCmd.Interrupt = interrupt
+	err = vcsCmd.Create(dir, "https://example.com/repo")
+	if _, is := err.(ErrInterrupted); !is {
+		t.Fatalf("create: got error %v", err)
+	}
+	for _, p := range []string{dir, dir + partialSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("create: %q was not removed", p)
+		}
+	}
+
+	// A create abandoned by an exit is removed when next opened.
+	g.Check(os.MkdirAll(filepath.Join(dir, ".git"), 0700))
+	g.Check(beginCreate(dir))
+	if !removeAbandoned(dir) {
+		t.Fatal("abandoned repo not found")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("abandoned repo %q was not removed", dir)
+	}
+	if removeAbandoned(dir) {
+		t.Error("removed abandoned repo twice")
+	}
+
+	// Operations are not started once interrupted.
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.Interrupt = interrupt
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	err = c.Alter()
+	if _, is := err.(ErrInterrupted); !is {
+		t.Fatalf("alter: got error %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(g.Current(), "vendor", "co2")); !os.IsNotExist(err) {
+		t.Error("alter: package copied after interrupt")
+	}
+}
+
+func TestTimeoutKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("process groups not supported")
+	}
+	// The child sleep keeps the output open if only sh is killed.
+	vcsCmd := &VCSCmd{
+		Cmd:     &vcs.Cmd{Name: "sh", Cmd: "sh"},
+		Timeout: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	_, err := vcsCmd.run1(".", "-c {script}", []string{"script", "sleep 10 & sleep 10"}, false)
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Fatalf("child process kept running, returned after %v", elapsed)
+	}
+}
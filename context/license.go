@@ -17,9 +17,9 @@ import (
 )
 
 type License struct {
-	Path     string
-	Filename string
-	Text     string
+	Path     string `json:"path"`
+	Filename string `json:"filename"`
+	Text     string `json:"text"`
 }
 
 type LicenseSort []License
@@ -0,0 +1,185 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// The go-import meta lookup of vcs.RepoRootForImportDynamic always uses
+// http.DefaultClient. It is done here instead with a client that adds
+// credentials, without changing the default client for the process.
+
+// httpClient returns a client that uses the credentials for HTTPS requests.
+func (a *Auth) httpClient() *http.Client {
+	return &http.Client{Transport: &authTransport{auth: a, base: http.DefaultTransport}}
+}
+
+// repoRootDynamic finds the repo root of importPath from its go-import
+// meta tags, as vcs.RepoRootForImportDynamic does.
+func (a *Auth) repoRootDynamic(importPath string) (*vcs.RepoRoot, error) {
+	slash := strings.Index(importPath, "/")
+	if slash < 0 {
+		slash = len(importPath)
+	}
+	host := importPath[:slash]
+	if !strings.Contains(host, ".") {
+		return nil, errors.New("import path doesn't contain a hostname")
+	}
+	client := a.httpClient()
+	urlStr, imports, err := fetchMetaImports(client, importPath)
+	if err != nil {
+		return nil, err
+	}
+	mi, err := matchGoImport(imports, importPath)
+	if err != nil {
+		if err != errNoMatch {
+			return nil, fmt.Errorf("parse %s: %v", urlStr, err)
+		}
+		return nil, fmt.Errorf("parse %s: no go-import meta tags", urlStr)
+	}
+	// Verify a meta tag for a prefix of the import path with the prefix
+	// itself, so a sub-path can't claim its parent.
+	if mi.Prefix != importPath {
+		urlStr0 := urlStr
+		urlStr, imports, err = fetchMetaImports(client, mi.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		mi2, err := matchGoImport(imports, importPath)
+		if err != nil || mi != mi2 {
+			return nil, fmt.Errorf("%s and %s disagree about go-import for %s", urlStr0, urlStr, mi.Prefix)
+		}
+	}
+	if u, err := url.Parse(mi.RepoRoot); err != nil || len(u.Scheme) == 0 {
+		return nil, fmt.Errorf("%s: invalid repo root %q", urlStr, mi.RepoRoot)
+	}
+	rr := &vcs.RepoRoot{
+		VCS:  vcs.ByCmd(mi.VCS),
+		Repo: mi.RepoRoot,
+		Root: mi.Prefix,
+	}
+	if rr.VCS == nil {
+		return nil, fmt.Errorf("%s: unknown vcs %q", urlStr, mi.VCS)
+	}
+	return rr, nil
+}
+
+// fetchMetaImports reads the go-import meta tags of importPath over HTTPS,
+// or HTTP if that fails.
+func fetchMetaImports(client *http.Client, importPath string) (string, []metaImport, error) {
+	fetch := func(scheme string) (string, *http.Response, error) {
+		u, err := url.Parse(scheme + "://" + importPath)
+		if err != nil {
+			return "", nil, err
+		}
+		u.RawQuery = "go-get=1"
+		res, err := client.Get(u.String())
+		return u.String(), res, err
+	}
+	urlStr, res, err := fetch("https")
+	if err != nil || res.StatusCode != 200 {
+		if res != nil {
+			res.Body.Close()
+		}
+		urlStr, res, err = fetch("http")
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("http/https fetch: %v", err)
+	}
+	defer res.Body.Close()
+	// A non-200 response is accepted, so the meta tags may be served
+	// with a 404 page.
+	imports, err := parseMetaGoImports(res.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing %s: %v", importPath, err)
+	}
+	return urlStr, imports, nil
+}
+
+// metaImport is a parsed <meta name="go-import" content="prefix vcs reporoot">.
+type metaImport struct {
+	Prefix, VCS, RepoRoot string
+}
+
+var errNoMatch = errors.New("no import match")
+
+// matchGoImport returns the meta import that is a path prefix of
+// importPath. It is an error if more than one matches.
+func matchGoImport(imports []metaImport, importPath string) (metaImport, error) {
+	match := -1
+	for i, im := range imports {
+		if !strings.HasPrefix(importPath, im.Prefix) {
+			continue
+		}
+		if rem := importPath[len(im.Prefix):]; len(rem) > 0 && rem[0] != '/' {
+			continue
+		}
+		if match != -1 {
+			return metaImport{}, fmt.Errorf("multiple meta tags match import path %q", importPath)
+		}
+		match = i
+	}
+	if match == -1 {
+		return metaImport{}, errNoMatch
+	}
+	return imports[match], nil
+}
+
+// parseMetaGoImports returns the go-import meta tags in the head of the
+// HTML in r.
+func parseMetaGoImports(r io.Reader) ([]metaImport, error) {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		if strings.ToLower(charset) == "ascii" {
+			return input, nil
+		}
+		return nil, fmt.Errorf("can't decode XML document using charset %q", charset)
+	}
+	d.Strict = false
+	var imports []metaImport
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			if err == io.EOF || len(imports) > 0 {
+				err = nil
+			}
+			return imports, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			if strings.EqualFold(e.Name.Local, "body") {
+				return imports, nil
+			}
+			if !strings.EqualFold(e.Name.Local, "meta") || attrValue(e.Attr, "name") != "go-import" {
+				continue
+			}
+			if f := strings.Fields(attrValue(e.Attr, "content")); len(f) == 3 && f[1] != "mod" {
+				imports = append(imports, metaImport{Prefix: f[0], VCS: f[1], RepoRoot: f[2]})
+			}
+		case xml.EndElement:
+			if strings.EqualFold(e.Name.Local, "head") {
+				return imports, nil
+			}
+		}
+	}
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -9,8 +9,6 @@ package context
 
 import (
 	"bytes"
-	"crypto/sha1"
-	"encoding/base64"
 	"fmt"
 	"math"
 	"path"
@@ -79,6 +77,10 @@ type Operation struct {
 
 	// True if the operation should treat the package as uncommitted.
 	Uncommitted bool
+
+	// The vendor file package before a fetch, nil if the fetch adds it.
+	// Restored if the fetch fails with KeepGoing.
+	before *vendorfile.Package
 }
 
 // Conflict reports packages that are scheduled to conflict.
@@ -327,7 +329,10 @@ func (ctx *Context) modify(ps *pkgspec.Pkg, mod Modify, mops []ModifyOption) err
 	}
 }
 
-func (ctx *Context) getIgnoreFiles(src string) (ignoreFile, imports []string, err error) {
+// getRulesIgnoreFiles returns the files in src ignored by the rules build
+// tags or prune patterns, and the imports of the other files. The folder
+// src is rel within the package.
+func (ctx *Context) getRulesIgnoreFiles(src, rel string, rules *pruneRules) (ignoreFile, imports []string, err error) {
 	srcDir, err := os.Open(src)
 	if err != nil {
 		return nil, nil, err
@@ -346,13 +351,20 @@ func (ctx *Context) getIgnoreFiles(src string) (ignoreFile, imports []string, er
 		if fi.Name()[0] == '.' {
 			continue
 		}
+		if rules.prune(path.Join(rel, fi.Name()), false) {
+			ignoreFile = append(ignoreFile, fi.Name())
+			continue
+		}
 		tags, fileImports, err := ctx.getFileTags(filepath.Join(src, fi.Name()), nil)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		if tags.IgnoreItem(ctx.ignoreTag...) {
+		if tags.IgnoreItem(rules.ignoreTag...) {
 			ignoreFile = append(ignoreFile, fi.Name())
+		} else if ctx.platformExcluded(tags) {
+			ignoreFile = append(ignoreFile, fi.Name())
+			rules.excluded = append(rules.excluded, path.Join(rel, fi.Name()))
 		} else {
 			// Only add imports for non-ignored files.
 			for _, imp := range fileImports {
@@ -379,7 +391,7 @@ func (ctx *Context) modifyAdd(pkg *Package, uncommitted bool) error {
 		ignoreFile = cpkg.ignoreFile
 	} else {
 		var err error
-		ignoreFile, _, err = ctx.getIgnoreFiles(src)
+		ignoreFile, _, err = ctx.packageIgnoreFiles(pkg.Path, src)
 		if err != nil {
 			return err
 		}
@@ -501,6 +513,7 @@ func (ctx *Context) modifyRemove(pkg *Package) error {
 // modify function to fetch given package.
 func (ctx *Context) modifyFetch(pkg *Package, uncommitted, hasVersion bool, version string) error {
 	vp := ctx.VendorFilePackagePath(pkg.Path)
+	before := savePackage(vp)
 	if vp == nil {
 		vp = &vendorfile.Package{
 			Add:  true,
@@ -530,14 +543,40 @@ func (ctx *Context) modifyFetch(pkg *Package, uncommitted, hasVersion bool, vers
 	}
 	dest := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(pkg.Path))
 	ctx.Operation = append(ctx.Operation, &Operation{
-		Type: OpFetch,
-		Pkg:  pkg,
-		Src:  ps.String(),
-		Dest: dest,
+		Type:   OpFetch,
+		Pkg:    pkg,
+		Src:    ps.String(),
+		Dest:   dest,
+		before: before,
 	})
 	return nil
 }
 
+// savePackage returns a copy of vp, or nil if vp is nil.
+func savePackage(vp *vendorfile.Package) *vendorfile.Package {
+	if vp == nil {
+		return nil
+	}
+	saved := *vp
+	return &saved
+}
+
+// restoreFetch restores the vendor file package of a failed fetch to its
+// value before the fetch, or removes it if the fetch added it.
+func (ctx *Context) restoreFetch(op *Operation) {
+	for i, vp := range ctx.VendorFile.Package {
+		if vp.Remove || vp.Path != op.Pkg.Path {
+			continue
+		}
+		if op.before != nil {
+			*vp = *op.before
+			return
+		}
+		ctx.VendorFile.Package = append(ctx.VendorFile.Package[:i], ctx.VendorFile.Package[i+1:]...)
+		return
+	}
+}
+
 // Check returns any conflicts when more than one package can be moved into
 // the same path.
 func (ctx *Context) Check() []*Conflict {
@@ -672,7 +711,16 @@ func (ctx *Context) ResolveAutoVendorFileOrigin(cc []*Conflict) []*Conflict {
 	return cc
 }
 
-// Alter runs any requested package alterations.
+// Alter runs any requested package alterations. Changes to the vendor
+// folder are staged and only applied, along with writing the vendor file,
+// if all of them succeed. Otherwise the vendor folder and vendor file are
+// left unchanged. With KeepGoing, if only some remote operations failed,
+// the others are applied and ErrRemoteFailures is returned. The vendor
+// file is not written if no package was altered.
+//
+// Imports are rewritten in place once the vendor folder is replaced, as
+// files outside of it change too. If that fails the vendor folder and
+// vendor file are already updated.
 func (ctx *Context) Alter() error {
 	ctx.added = nil
 	// Ensure there are no conflicts at this time.
@@ -687,17 +735,68 @@ func (ctx *Context) Alter() error {
 		return errors.New(buf.String())
 	}
 
+	ready := false
+	for _, op := range ctx.Operation {
+		if op.State == OpReady {
+			ready = true
+			break
+		}
+	}
+	if !ready {
+		err := ctx.alter()
+		if err != nil {
+			return err
+		}
+		return ctx.rewrite()
+	}
+	tx, err := ctx.beginTx()
+	if err != nil {
+		return err
+	}
+	err = ctx.alter()
+	if _, is := err.(ErrRemoteFailures); err != nil && !is || !ctx.altered() {
+		tx.rollback()
+		return err
+	}
+	// Commit any successful changes with KeepGoing.
+	cerr := tx.commit()
+	if cerr != nil {
+		return cerr
+	}
+	cerr = ctx.rewrite()
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// altered reports if any operation was done.
+func (ctx *Context) altered() bool {
+	for _, op := range ctx.Operation {
+		if op.State == OpDone {
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *Context) alter() error {
 	var err error
 	fetch, err := newFetcher(ctx)
 	if err != nil {
 		return err
 	}
+	// With KeepGoing, collect fetch failures and return them at the end.
+	rem := ErrRemoteFailures{}
 	for {
 		var nextOps []*Operation
 		for _, op := range ctx.Operation {
 			if op.State != OpReady {
 				continue
 			}
+			if err = ctx.interrupted(); err != nil {
+				return err
+			}
 
 			switch op.Type {
 			case OpFetch:
@@ -709,7 +808,18 @@ func (ctx *Context) Alter() error {
 				}
 			}
 			if err != nil {
-				return errors.Wrapf(err, "Failed to fetch package %q", op.Pkg.Path)
+				if ierr := ctx.interrupted(); ierr != nil {
+					return ierr
+				}
+				// A patch conflict is not a remote failure, always stop.
+				_, conflict := errors.Cause(err).(ErrPatchConflict)
+				if !ctx.KeepGoing || conflict {
+					return errors.Wrapf(err, "Failed to fetch package %q", op.Pkg.Path)
+				}
+				rem = append(rem, RemoteFailure{Msg: "failed to fetch package", Path: op.Pkg.Path, Err: err})
+				op.State = OpIgnore
+				ctx.restoreFetch(op)
+				err = nil
 			}
 		}
 		if len(nextOps) == 0 {
@@ -722,6 +832,9 @@ func (ctx *Context) Alter() error {
 		if op.State != OpReady {
 			continue
 		}
+		if err = ctx.interrupted(); err != nil {
+			return err
+		}
 		pkg := op.Pkg
 
 		if pathos.FileStringEquals(op.Dest, op.Src) {
@@ -734,7 +847,16 @@ func (ctx *Context) Alter() error {
 			panic("unknown operation type")
 		case OpRemove:
 			ctx.dirty = true
-			err = RemovePackage(op.Src, filepath.Join(ctx.RootDir, ctx.VendorFolder), pkg.IncludeTree)
+			span := ctx.startEvent(Event{Kind: EventRemove, Package: pkg.Path, Path: op.Src})
+			size := ctx.folderSize(ctx.stagePath(op.Src))
+			err = RemovePackage(ctx.stagePath(op.Src), ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder)), pkg.IncludeTree)
+			if vp := ctx.removedPackage(pkg.Path); vp != nil && err == nil {
+				err = ctx.removeExtraFiles(pkg.Path, strings.Fields(vp.ExtraFiles), nil)
+				if err == nil {
+					err = ctx.removePatches(vp)
+				}
+			}
+			span.finish(size, err)
 			op.State = OpDone
 		case OpCopy:
 			err = ctx.copyOperation(op, nil)
@@ -747,8 +869,8 @@ func (ctx *Context) Alter() error {
 			return errors.Wrapf(err, "Failed to %v package %q -> %q", op.Type, op.Src, op.Dest)
 		}
 	}
-	if ctx.rewriteImports {
-		return ctx.rewrite()
+	if len(rem) > 0 {
+		return rem
 	}
 	return nil
 }
@@ -757,22 +879,42 @@ func (ctx *Context) copyOperation(op *Operation, beforeCopy func(deps []string)
 	var err error
 	pkg := op.Pkg
 	ctx.dirty = true
-	h := sha1.New()
-	var checksum []byte
+	h := newPackageHash()
 
 	root, _ := pathos.TrimCommonSuffix(op.Src, pkg.Path)
 
-	err = ctx.CopyPackage(op.Dest, op.Src, root, pkg.Path, op.IgnoreFile, pkg.IncludeTree, h, beforeCopy)
-	if err == nil && !op.Uncommitted {
-		checksum = h.Sum(nil)
-		vpkg := ctx.VendorFilePackagePath(pkg.Path)
-		if vpkg != nil {
-			vpkg.ChecksumSHA1 = base64.StdEncoding.EncodeToString(checksum)
-		}
+	span := ctx.startEvent(Event{Kind: EventCopy, Package: pkg.Path, Path: op.Dest})
+	var extra []string
+	if vpkg := ctx.VendorFilePackagePath(pkg.Path); vpkg != nil {
+		span.Revision = vpkg.Revision
+		extra = strings.Fields(vpkg.ExtraFiles)
 	}
+	err = ctx.CopyPackage(ctx.stagePath(op.Dest), op.Src, root, pkg.Path, op.IgnoreFile, pkg.IncludeTree, h, beforeCopy)
+	span.Files = len(h.files)
+	span.finish(h.n, err)
 	op.State = OpDone
 	if err != nil {
 		return errors.Wrapf(err, "copy failed. dest: %q, src: %q, pkgPath %q", op.Dest, op.Src, root)
 	}
+	vpkg := ctx.VendorFilePackagePath(pkg.Path)
+	if vpkg == nil {
+		return nil
+	}
+	// Remove the extra files the package no longer references.
+	err = ctx.removeExtraFiles(pkg.Path, extra, strings.Fields(vpkg.ExtraFiles))
+	if err != nil {
+		return err
+	}
+	// The checksum covers the patched package.
+	patched, err := ctx.patchPackage(vpkg)
+	if err != nil {
+		return err
+	}
+	if patched != nil {
+		h = patched
+	}
+	if !op.Uncommitted {
+		ctx.setChecksum(vpkg, h)
+	}
 	return nil
 }
@@ -0,0 +1,223 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/diff"
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/pkgspec"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// PatchFolder is the folder in the vendor folder with the patches of each
+// package, as "_patches/<package path>/<name>.patch". Patches listed in
+// the vendor file package are applied in order after the package is copied.
+// The "_" prefix keeps the go tool from reading the folder.
+const PatchFolder = "_patches"
+
+// patchDir returns the folder of the package patches.
+func (ctx *Context) patchDir(pkgPath string) string {
+	return ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder, PatchFolder, pathos.SlashToFilepath(pkgPath)))
+}
+
+// patchPackage applies the vendor file package patches to the package in
+// the vendor folder and returns the hash of the patched package. If the
+// package has no patches the returned hash is nil.
+func (ctx *Context) patchPackage(vp *vendorfile.Package) (*packageHash, error) {
+	patches := strings.Fields(vp.Patches)
+	if len(patches) == 0 {
+		return nil, nil
+	}
+	dest := ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path)))
+	for _, name := range patches {
+		fmt.Fprintf(ctx, "Apply patch %q to %q\n", name, vp.Path)
+		err := applyPatch(filepath.Join(ctx.patchDir(vp.Path), name), dest)
+		if err != nil {
+			return nil, ErrPatchConflict{ImportPath: vp.Path, Patch: name, Err: err}
+		}
+	}
+	return ctx.hashVendorPackage(vp)
+}
+
+// removePatches removes the patches of the vendor file package vp, which
+// is being removed, and the patch folders left empty.
+func (ctx *Context) removePatches(vp *vendorfile.Package) error {
+	dir := ctx.patchDir(vp.Path)
+	for _, name := range strings.Fields(vp.Patches) {
+		err := os.Remove(filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	removeEmptyFolders(dir, ctx.stagePath(filepath.Join(ctx.RootDir, ctx.VendorFolder)))
+	return nil
+}
+
+// applyPatch applies the unified diff in patchFile to the files in dir.
+func applyPatch(patchFile, dir string) error {
+	b, err := ioutil.ReadFile(patchFile)
+	if err != nil {
+		return err
+	}
+	list, err := diff.Parse(b)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no file changes found")
+	}
+	filePath := func(name string) (string, error) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if len(name) == 0 || !pathInDir(p, dir) || p == dir {
+			return "", fmt.Errorf("file %q is not in the package", name)
+		}
+		return p, nil
+	}
+	for _, fp := range list {
+		var content []byte
+		perm := os.FileMode(0666)
+		if len(fp.Old) > 0 {
+			oldPath, err := filePath(fp.Old)
+			if err != nil {
+				return err
+			}
+			fi, err := os.Stat(oldPath)
+			if err != nil {
+				return err
+			}
+			perm = fi.Mode()
+			content, err = ioutil.ReadFile(oldPath)
+			if err != nil {
+				return err
+			}
+			// Remove rather than write in place, the file may be a hard
+			// link to a file in the vendor folder. See vendorTx.
+			err = os.Remove(oldPath)
+			if err != nil {
+				return err
+			}
+		}
+		content, err = fp.Apply(content)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fp.Old, err)
+		}
+		if len(fp.New) == 0 {
+			continue
+		}
+		newPath, err := filePath(fp.New)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("%s: file already exists", fp.New)
+		}
+		err = os.MkdirAll(filepath.Dir(newPath), 0777)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(newPath, content, perm)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePatch records the local modifications of the vendor package as a
+// new patch in the patch folder and adds it to the vendor file package.
+// The modifications are compared to the cached repo at the recorded
+// revision with any existing patches applied. The name describes the
+// patch and returns the patch file name. The vendor file is not written.
+func (ctx *Context) CreatePatch(ps *pkgspec.Pkg, name string) (string, error) {
+	vp := ctx.VendorFilePackagePath(ps.Path)
+	if vp == nil {
+		return "", fmt.Errorf("Package %q is not in the vendor file", ps.Path)
+	}
+	if len(vp.Revision) == 0 {
+		return "", fmt.Errorf("Package %q has no revision in the vendor file", ps.Path)
+	}
+	from := vp.PathOrigin()
+	srcDir, err := ctx.cacheCheckout(from, vp.Revision)
+	if err != nil {
+		return "", err
+	}
+
+	// Recreate the package as it would be copied to the vendor folder.
+	base, err := ioutil.TempDir("", "govendor-patch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(base)
+	srcFiles, err := ctx.packageFiles(srcDir, vp.Path, vp.Tree)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range srcFiles {
+		dest := filepath.Join(base, pathos.SlashToFilepath(file))
+		err = os.MkdirAll(filepath.Dir(dest), 0777)
+		if err != nil {
+			return "", err
+		}
+		err = copyFile(dest, filepath.Join(srcDir, pathos.SlashToFilepath(file)), nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	patches := strings.Fields(vp.Patches)
+	for _, patch := range patches {
+		err = applyPatch(filepath.Join(ctx.patchDir(vp.Path), patch), base)
+		if err != nil {
+			return "", ErrPatchConflict{ImportPath: vp.Path, Patch: patch, Err: err}
+		}
+	}
+	baseFiles, err := vendorPackageFiles(base, vp.Tree)
+	if err != nil {
+		return "", err
+	}
+
+	vendorDir := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path))
+	vendorFiles, err := vendorPackageFiles(vendorDir, vp.Tree)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	err = writeDirDiff(buf, base, "a", baseFiles, vendorDir, "b", vendorFiles)
+	if err != nil {
+		return "", err
+	}
+	if buf.Len() == 0 {
+		return "", fmt.Errorf("Package %q has no local modifications", vp.Path)
+	}
+
+	if len(name) == 0 {
+		name = "local"
+	}
+	patchName := fmt.Sprintf("%04d-%s.patch", len(patches)+1, name)
+	patchDir := ctx.patchDir(vp.Path)
+	err = os.MkdirAll(patchDir, 0777)
+	if err != nil {
+		return "", err
+	}
+	err = ioutil.WriteFile(filepath.Join(patchDir, patchName), buf.Bytes(), 0666)
+	if err != nil {
+		return "", err
+	}
+	vp.Patches = strings.Join(append(patches, patchName), " ")
+
+	// The patched package is now the expected vendor package.
+	h, err := ctx.hashVendorPackage(vp)
+	if err != nil {
+		return "", err
+	}
+	ctx.setChecksum(vp, h)
+	return patchName, nil
+}
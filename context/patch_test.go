@@ -0,0 +1,134 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"github.com/pkg/errors"
+)
+
+func TestPatch(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	writeFile(t, filepath.Join(g.Path("remote/co2/pk1"), "a.go"), "package pk1\n\nconst A = 1\n")
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	remote.Setup().Commit()
+
+	g.In("co1")
+	c := ctx(g)
+	remotePkg := remote.HttpAddr() + "/remote/co2/pk1"
+	g.Check(c.ModifyImport(pkg(remotePkg), Fetch))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	vendorPkg := filepath.Join(g.Current(), "vendor", filepath.FromSlash(remotePkg))
+	fixed := "package pk1\n\nconst A = 2\n"
+	writeFile(t, filepath.Join(vendorPkg, "a.go"), fixed)
+
+	name, err := c.CreatePatch(pkg(remotePkg), "fix")
+	g.Check(err)
+	if name != "0001-fix.patch" {
+		t.Fatalf("unexpected patch name %q", name)
+	}
+	g.Check(c.WriteVendorFile())
+	patchFile := filepath.Join(g.Current(), "vendor", PatchFolder, filepath.FromSlash(remotePkg), name)
+	if _, err := os.Stat(patchFile); err != nil {
+		t.Fatal(err)
+	}
+	verifyChecksum(g, c, "after create")
+
+	_, err = c.CreatePatch(pkg(remotePkg), "again")
+	if err == nil {
+		t.Fatal("expected no local modifications error")
+	}
+
+	// Copying the package again keeps the patch.
+	c = ctx(g)
+	if c.VendorFilePackagePath(remotePkg).Patches != name {
+		t.Fatalf("patch not recorded in the vendor file")
+	}
+	g.Check(c.ModifyImport(pkg(remotePkg), Fetch))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+	checkContent := func(when string) {
+		got, err := ioutil.ReadFile(filepath.Join(vendorPkg, "a.go"))
+		g.Check(err)
+		if string(got) != fixed {
+			t.Fatalf("(%s) patch not applied, got %q", when, got)
+		}
+	}
+	checkContent("fetch")
+	verifyChecksum(g, c, "after fetch")
+
+	// Sync restores the patched package.
+	g.Check(os.RemoveAll(vendorPkg))
+	c = ctx(g)
+	g.Check(c.Sync(false))
+	checkContent("sync")
+	verifyChecksum(g, c, "after sync")
+
+	// A patch that no longer applies fails and the vendor folder is unchanged.
+	writeFile(t, patchFile, `--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package pk1
+
+-const A = 3
++const A = 4
+`)
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg(remotePkg), Fetch))
+	err = c.Alter()
+	if _, is := errors.Cause(err).(ErrPatchConflict); !is {
+		t.Fatalf("expected patch conflict, got %v", err)
+	}
+	checkContent("conflict")
+
+	// Sync with a patch that no longer applies leaves the vendor folder
+	// and vendor file unchanged.
+	vendorFilePath := filepath.Join(g.Current(), "vendor", "vendor.json")
+	before, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	modified := "package pk1\n\nconst A = 5\n"
+	writeFile(t, filepath.Join(vendorPkg, "a.go"), modified)
+	c = ctx(g)
+	err = c.Sync(false)
+	if _, is := errors.Cause(err).(ErrPatchConflict); !is {
+		t.Fatalf("expected patch conflict, got %v", err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(vendorPkg, "a.go"))
+	g.Check(err)
+	if string(got) != modified {
+		t.Fatalf("vendor folder changed, got %q", got)
+	}
+	after, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	if string(before) != string(after) {
+		t.Fatalf("vendor file changed\n%s", after)
+	}
+
+	// Removing the package removes its patches.
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg(remotePkg), Remove))
+	g.Check(c.Alter())
+	if _, err := os.Stat(filepath.Join(g.Current(), "vendor", PatchFolder)); !os.IsNotExist(err) {
+		t.Fatalf("patches not removed: %v", err)
+	}
+}
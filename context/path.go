@@ -19,17 +19,7 @@ func (ctx *Context) isStdLib(importPath string) (yes bool, err error) {
 		return
 	}
 
-	dir := filepath.Join(ctx.Goroot, importPath)
-	fi, _ := os.Stat(dir)
-	if fi == nil {
-		return
-	}
-	if !fi.IsDir() {
-		return
-	}
-
-	yes, err = hasGoFileInFolder(dir)
-	return
+	return ctx.stdIndex()[importPath], nil
 }
 
 // findImportDir finds the absolute directory. If rel is empty vendor folders
@@ -72,7 +62,10 @@ func (ctx *Context) findImportDir(relative, importPath string) (dir, gopath stri
 
 	}
 	for _, gopath = range ctx.GopathList {
-		dir := filepath.Join(gopath, importPath)
+		dir := ctx.importDir(gopath, importPath)
+		if len(dir) == 0 {
+			continue
+		}
 		fi, err := os.Stat(dir)
 		if os.IsNotExist(err) {
 			continue
@@ -153,6 +146,17 @@ func hasGoFileInFolder(folder string) (bool, error) {
 	return false, nil
 }
 
+// removeEmptyFolders removes dir and its parent folders while they are
+// empty, up to but not including root. os.Remove fails on the first folder
+// that is not empty.
+func removeEmptyFolders(dir, root string) {
+	for ; !pathos.FileStringEquals(dir, root) && pathos.FileHasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
 // RemovePackage removes the specified folder files. If folder is empty when
 // done (no nested folders, remove the folder and any empty parent folders.
 func RemovePackage(path, root string, tree bool) error {
@@ -0,0 +1,130 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Platform is a GOOS and GOARCH pair packages are vendored for.
+type Platform struct {
+	GOOS, GOARCH string
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// unixOS are the GOOS values that also satisfy the "unix" build tag.
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+	"linux": true, "netbsd": true, "openbsd": true, "solaris": true,
+}
+
+// impliedOS are the GOOS values that also satisfy the tag of another GOOS.
+var impliedOS = map[string]string{
+	"android": "linux",
+	"illumos": "solaris",
+	"ios":     "darwin",
+}
+
+// ParsePlatforms parses a space separated list of "goos/goarch" pairs.
+func ParsePlatforms(s string) ([]Platform, error) {
+	var list []Platform
+	for _, field := range strings.Fields(s) {
+		i := strings.IndexByte(field, '/')
+		if i < 0 {
+			return nil, fmt.Errorf("Platform %q must be in the form goos/goarch", field)
+		}
+		p := Platform{GOOS: field[:i], GOARCH: field[i+1:]}
+		if !knownOS[p.GOOS] {
+			return nil, fmt.Errorf("Unknown GOOS %q in platform %q", p.GOOS, field)
+		}
+		if !knownArch[p.GOARCH] {
+			return nil, fmt.Errorf("Unknown GOARCH %q in platform %q", p.GOARCH, field)
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// Platforms returns the target platforms of the vendor file. If empty
+// packages are vendored for all platforms.
+func (ctx *Context) Platforms() []Platform {
+	return ctx.platforms
+}
+
+// platformExcluded reports if the file tags can't build for any of the
+// target platforms.
+func (ctx *Context) platformExcluded(tags *TagSet) bool {
+	if len(ctx.platforms) == 0 || tags == nil {
+		return false
+	}
+	for _, p := range ctx.platforms {
+		if tags.BuildsFor(p.GOOS, p.GOARCH) {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildsFor reports if the file may build for goos and goarch. Tags other
+// than known GOOS and GOARCH values may be set either way.
+func (ts *TagSet) BuildsFor(goos, goarch string) bool {
+	if ts == nil {
+		return true
+	}
+	if ts.ignore {
+		return false
+	}
+	value := func(lt logicalTag) bool {
+		var set bool
+		switch {
+		case lt.tag == goos, lt.tag == goarch, lt.tag == impliedOS[goos]:
+			set = true
+		case lt.tag == "unix":
+			set = unixOS[goos]
+		case knownOS[lt.tag], knownArch[lt.tag]:
+			set = false
+		default:
+			return true
+		}
+		return set != lt.not
+	}
+	return ts.root.eval(value)
+}
+
+// eval returns the value of the logical. An empty logical is true.
+func (l logical) eval(value func(lt logicalTag) bool) bool {
+	if l.empty() {
+		return true
+	}
+	if l.and {
+		for _, t := range l.tag {
+			if !value(t) {
+				return false
+			}
+		}
+		for _, sub := range l.sub {
+			if !sub.empty() && !sub.eval(value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, t := range l.tag {
+		if value(t) {
+			return true
+		}
+	}
+	for _, sub := range l.sub {
+		if !sub.empty() && sub.eval(value) {
+			return true
+		}
+	}
+	return false
+}
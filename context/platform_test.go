@@ -0,0 +1,126 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestBuildsFor(t *testing.T) {
+	list := []struct {
+		file      []string
+		buildTags string
+		goBuild   string
+		builds    string // Platforms the file builds for.
+	}{
+		{builds: "linux/amd64 linux/arm64 windows/amd64 plan9/386"},
+		{file: []string{"windows"}, builds: "windows/amd64"},
+		{file: []string{"linux", "arm64"}, builds: "linux/arm64"},
+		{file: []string{"test"}, builds: "linux/amd64 linux/arm64 windows/amd64 plan9/386"},
+		{buildTags: "!windows", builds: "linux/amd64 linux/arm64 plan9/386"},
+		{buildTags: "linux,amd64 plan9", builds: "linux/amd64 plan9/386"},
+		{buildTags: "appengine", builds: "linux/amd64 linux/arm64 windows/amd64 plan9/386"},
+		{buildTags: "ignore", builds: ""},
+		{goBuild: "unix && !arm64", builds: "linux/amd64"},
+		{goBuild: "(windows || plan9) && cgo", builds: "windows/amd64 plan9/386"},
+		{goBuild: "!(linux && amd64)", builds: "linux/arm64 windows/amd64 plan9/386"},
+		{file: []string{"linux"}, goBuild: "386", builds: ""},
+	}
+	platforms, err := ParsePlatforms("linux/amd64 linux/arm64 windows/amd64 plan9/386")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, item := range list {
+		ts := &TagSet{}
+		for _, f := range item.file {
+			ts.AddFileTag(f)
+		}
+		if len(item.buildTags) > 0 {
+			ts.AddBuildTags(item.buildTags)
+		}
+		if len(item.goBuild) > 0 {
+			if err := ts.AddBuildExpr(item.goBuild); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var builds []string
+		for _, p := range platforms {
+			if ts.BuildsFor(p.GOOS, p.GOARCH) {
+				builds = append(builds, p.String())
+			}
+		}
+		if got := strings.Join(builds, " "); got != item.builds {
+			t.Errorf("index %d: got %q, want %q: %v", index, got, item.builds, ts)
+		}
+	}
+}
+
+func TestParsePlatforms(t *testing.T) {
+	for _, s := range []string{"linux", "linux/", "nope/amd64", "linux/nope"} {
+		if _, err := ParsePlatforms(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+		gt.File("a_windows.go", "co3/win"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("a_linux.go", "strings"),
+		gt.File("a_windows.go", "co3/win"),
+		gt.FileBuild("b.go", "plan9", "co3/win"),
+	)
+	writeFile(t, filepath.Join(g.Path("co2/pk1"), "c.go"), "//go:build darwin || (linux && 386)\n\npackage pk1\n\nimport \"co3/win\"\n")
+	g.Setup("co3/win",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.VendorFile.Platforms = "linux/amd64 linux/arm64"
+	g.Check(c.WriteVendorFile())
+
+	c = ctx(g)
+	list(g, c, "before", `
+ e  co2/pk1 < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co2/pk1"]
+`)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	tree(g, "after", `
+/pk1/a.go
+/pk1/a_windows.go
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/a_linux.go
+/vendor/vendor.json
+`)
+	c = ctx(g)
+	items, err := c.Status()
+	g.Check(err)
+	got := make(map[string]string, len(items))
+	for _, item := range items {
+		got[item.Pkg.Path] = strings.Join(item.PlatformExcluded, " ")
+	}
+	if got["co1/pk1"] != "a_windows.go" {
+		t.Errorf("local excluded: %q", got["co1/pk1"])
+	}
+	if got["co2/pk1"] != "a_windows.go b.go c.go" {
+		t.Errorf("vendor excluded: %q", got["co2/pk1"])
+	}
+	verifyChecksum(g, c, "after")
+}
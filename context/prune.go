@@ -0,0 +1,178 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// pruneRules are the build tags and file patterns a package is copied and
+// hashed with. The vendor file "ignore" and "prune" fields apply to every
+// package, a package may add to them with its own "ignore" and "prune".
+type pruneRules struct {
+	ignoreTag []string // Build tags to ignore.
+	ownTags   bool     // True if the package changes the vendor file tags.
+
+	patterns []prunePattern
+
+	// excluded are the files, relative to the package folder, that can't
+	// build for any target platform. Added to as folders are read.
+	excluded []string
+
+	// copied are the files, relative to the package folder, copied with the
+	// package, and refs the references of the copied Go files.
+	copied map[string]bool
+	refs   []packageRef
+}
+
+type prunePattern struct {
+	match  string // Glob pattern without any trailing "/".
+	dir    bool   // Only matches folders.
+	rooted bool   // Matches the path relative to the package folder, not the name.
+}
+
+// parsePrune parses a space separated list of prune patterns.
+func parsePrune(s string) ([]prunePattern, error) {
+	var list []prunePattern
+	for _, field := range strings.Fields(s) {
+		p := prunePattern{match: strings.Trim(field, "/")}
+		p.dir = strings.HasSuffix(field, "/")
+		p.rooted = strings.Contains(p.match, "/")
+		if len(p.match) == 0 {
+			return nil, fmt.Errorf("Invalid prune pattern %q", field)
+		}
+		if _, err := path.Match(p.match, ""); err != nil {
+			return nil, fmt.Errorf("Invalid prune pattern %q: %v", field, err)
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// checkPrune reports any invalid prune pattern in the vendor file.
+func checkPrune(vf *vendorfile.File) error {
+	if _, err := parsePrune(vf.Prune); err != nil {
+		return err
+	}
+	for _, vp := range vf.Package {
+		if _, err := parsePrune(vp.Prune); err != nil {
+			return fmt.Errorf("Package %q: %v", vp.Path, err)
+		}
+	}
+	return nil
+}
+
+// pruneRules returns the rules for the package pkgPath. Patterns are
+// checked when the vendor file is read so errors are not returned here.
+func (ctx *Context) pruneRules(pkgPath string) *pruneRules {
+	r := &pruneRules{ignoreTag: ctx.ignoreTag}
+	if ctx.VendorFile == nil {
+		return r
+	}
+	r.patterns, _ = parsePrune(ctx.VendorFile.Prune)
+	vp := ctx.VendorFilePackagePath(pkgPath)
+	if vp == nil {
+		return r
+	}
+	own, _ := parsePrune(vp.Prune)
+	r.patterns = append(r.patterns, own...)
+
+	tags := strings.Fields(vp.Ignore)
+	if len(tags) == 0 {
+		return r
+	}
+	r.ownTags = true
+	keep := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "!") {
+			keep[tag[1:]] = true
+		}
+	}
+	r.ignoreTag = make([]string, 0, len(ctx.ignoreTag)+len(tags))
+	for _, tag := range ctx.ignoreTag {
+		if !keep[tag] {
+			r.ignoreTag = append(r.ignoreTag, tag)
+			keep[tag] = true
+		}
+	}
+	for _, tag := range tags {
+		if !keep[tag] && !strings.HasPrefix(tag, "!") {
+			r.ignoreTag = append(r.ignoreTag, tag)
+			keep[tag] = true
+		}
+	}
+	return r
+}
+
+// ignoreTest reports if test files and folders are not copied.
+func (r *pruneRules) ignoreTest() bool {
+	for _, ignore := range r.ignoreTag {
+		if ignore == "test" {
+			return true
+		}
+	}
+	return false
+}
+
+// prune reports if a pattern matches the file or folder rel, a slash
+// separated path relative to the package folder.
+func (r *pruneRules) prune(rel string, isDir bool) bool {
+	name := path.Base(rel)
+	for _, p := range r.patterns {
+		if p.dir && !isDir {
+			continue
+		}
+		subject := name
+		if p.rooted {
+			subject = rel
+		}
+		if ok, _ := path.Match(p.match, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir reports if the sub-folder rel of the package is not copied.
+func (r *pruneRules) skipDir(rel string, tree bool) bool {
+	name := path.Base(rel)
+	isTestdata := name == "testdata"
+	switch {
+	case name[0] == '.', name[0] == '_':
+		return true
+	case !tree && !isTestdata:
+		return true
+	case isTestdata || strings.HasSuffix(name, "_test"):
+		if r.ignoreTest() {
+			return true
+		}
+	}
+	return r.prune(rel, true)
+}
+
+// skipFile reports if the file rel of the package is not copied.
+func (r *pruneRules) skipFile(rel string, ignoreFiles []string) bool {
+	name := path.Base(rel)
+	if name[0] == '.' {
+		return true
+	}
+	for _, ignore := range ignoreFiles {
+		if pathos.FileStringEquals(name, ignore) {
+			return true
+		}
+	}
+	return r.prune(rel, false)
+}
+
+// packageIgnoreFiles returns the files in the package folder src ignored by
+// the rules of pkgPath, and the imports of the other files.
+func (ctx *Context) packageIgnoreFiles(pkgPath, src string) (ignoreFile, imports []string, err error) {
+	return ctx.getRulesIgnoreFiles(src, "", ctx.pruneRules(pkgPath))
+}
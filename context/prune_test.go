@@ -0,0 +1,115 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+func TestPruneRules(t *testing.T) {
+	c := &Context{
+		VendorFile: &vendorfile.File{
+			Prune: "*.pb.gw.go",
+			Package: []*vendorfile.Package{
+				{Path: "a", Prune: "examples/ internal/gen/*.go", Ignore: "!test appengine"},
+			},
+		},
+	}
+	c.IgnoreBuildAndPackage("test")
+
+	other := c.pruneRules("b")
+	if other.ownTags || !other.ignoreTest() {
+		t.Fatalf("unexpected rules for other package: %+v", other)
+	}
+	r := c.pruneRules("a")
+	if !r.ownTags || r.ignoreTest() || len(r.ignoreTag) != 1 || r.ignoreTag[0] != "appengine" {
+		t.Fatalf("unexpected package tags %q", r.ignoreTag)
+	}
+	list := []struct {
+		Rel   string
+		IsDir bool
+		Prune bool
+	}{
+		{"a.go", false, false},
+		{"api.pb.gw.go", false, true},
+		{"sub/api.pb.gw.go", false, true},
+		{"examples", true, true},
+		{"sub/examples", true, true},
+		{"examples", false, false},
+		{"internal/gen/x.go", false, true},
+		{"gen/x.go", false, false},
+	}
+	for _, item := range list {
+		if got := r.prune(item.Rel, item.IsDir); got != item.Prune {
+			t.Errorf("prune(%q, %t) = %t, want %t", item.Rel, item.IsDir, got, item.Prune)
+		}
+	}
+
+	if _, err := parsePrune("a[ /"); err == nil {
+		t.Error("expected invalid pattern errors")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("a.pb.gw.go", "strings"),
+		gt.File("a_test.go", "testing"),
+	)
+	g.Setup("co2/pk1/examples",
+		gt.File("a.go", "fmt"),
+	)
+	g.Setup("co2/pk1/sub",
+		gt.File("a.go", "strings"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("a_test.go", "testing"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.IgnoreBuildAndPackage("test")
+	c.VendorFile.Ignore = "test"
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Add))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Add))
+	g.Check(c.Alter())
+
+	c.VendorFilePackagePath("co2/pk1").Prune = "examples/"
+	c.VendorFilePackagePath("co3/pk1").Ignore = "!test"
+	c.VendorFile.Prune = "*.pb.gw.go"
+	g.Check(c.WriteVendorFile())
+
+	// Pruned files still in the vendor folder are not part of the checksum.
+	c = ctx(g)
+	out, err := c.VerifyVendor()
+	g.Check(err)
+	if len(out) != 1 || out[0].Path != "co2/pk1" {
+		t.Fatalf("expected co2/pk1 out of date, got %d", len(out))
+	}
+
+	g.Check(c.ModifyImport(pkg("co2/pk1/^"), Update))
+	g.Check(c.ModifyImport(pkg("co3/pk1"), Update))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	tree(g, "pruned", `
+/pk1/a.go
+/vendor/co2/pk1/a.go
+/vendor/co2/pk1/sub/a.go
+/vendor/co3/pk1/a.go
+/vendor/co3/pk1/a_test.go
+/vendor/vendor.json
+`)
+	verifyChecksum(g, ctx(g), "pruned")
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"time"
+
+	filepath "github.com/kardianos/govendor/internal/vfilepath"
+	os "github.com/kardianos/govendor/internal/vos"
+)
+
+// fileStamp notes the state of a file or folder to find changes to it.
+// A missing file has the zero stamp.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statStamp(p string) fileStamp {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+// parsedFile is a file parsed for its package name, imports and comments.
+type parsedFile struct {
+	stamp fileStamp
+	f     *ast.File
+}
+
+// parseImports parses the file package clause and imports. The parsed file
+// is kept and returned again until the file changes.
+func (ctx *Context) parseImports(pathname string) *ast.File {
+	stamp := statStamp(pathname)
+	if pf, found := ctx.parsed[pathname]; found && pf.stamp == stamp {
+		return pf.f
+	}
+	// Ignore error here and continue on best effort.
+	f, _ := parser.ParseFile(token.NewFileSet(), pathname, nil, parser.ImportsOnly|parser.ParseComments)
+	if ctx.parsed == nil {
+		ctx.parsed = make(map[string]parsedFile, 100)
+	}
+	ctx.parsed[pathname] = parsedFile{stamp: stamp, f: f}
+	return f
+}
+
+// readDir notes the folder read when loading packages. A file added to
+// or removed from the folder changes it.
+func (ctx *Context) readDir(dir string) {
+	if ctx.dirStamp == nil {
+		ctx.dirStamp = make(map[string]fileStamp, 50)
+	}
+	ctx.dirStamp[dir] = statStamp(dir)
+}
+
+// Reload finds the files and folders that changed since the packages and
+// vendor file were loaded, such as after editing code or checking out a
+// different revision. A changed vendor file is read again and the packages
+// are loaded again when next needed. Otherwise only the packages in the
+// changed folders are loaded again and the package status updated.
+// Returns the changed paths, sorted.
+func (ctx *Context) Reload() ([]string, error) {
+	var changed []string
+	if statStamp(ctx.VendorFilePath) != ctx.vendorStamp {
+		changed = append(changed, ctx.VendorFilePath)
+		err := ctx.loadVendorFile()
+		if err != nil {
+			return changed, err
+		}
+		// The vendor file sets the tree and origin of packages.
+		ctx.dirty = true
+	}
+	if !ctx.loaded || ctx.dirty {
+		// Loaded again when next needed in any case.
+		return changed, nil
+	}
+	dirs := make(map[string]bool, 5)
+	for dir, stamp := range ctx.dirStamp {
+		if statStamp(dir) != stamp {
+			changed = append(changed, dir)
+			dirs[dir] = true
+		}
+	}
+	for pathname, pf := range ctx.parsed {
+		if statStamp(pathname) != pf.stamp {
+			changed = append(changed, pathname)
+			delete(ctx.parsed, pathname)
+			dirs[filepath.Dir(pathname)] = true
+		}
+	}
+	sort.Strings(changed)
+	if len(dirs) == 0 {
+		return changed, nil
+	}
+	err := ctx.reloadDirs(dirs)
+	if err != nil {
+		// Load all packages again when next needed.
+		ctx.dirty = true
+		return changed, err
+	}
+	return changed, nil
+}
+
+// reloadDirs removes the packages in the changed dirs and adds the
+// packages in them again, then sets the status of all packages again.
+func (ctx *Context) reloadDirs(dirs map[string]bool) error {
+	ctx.statusCache = nil
+	removed := make(map[string]bool, len(dirs))
+	gopath := make(map[string]string, len(dirs))
+	for local, pkg := range ctx.Package {
+		if len(pkg.Dir) == 0 || !dirs[filepath.Clean(pkg.Dir)] {
+			continue
+		}
+		delete(ctx.Package, local)
+		removed[local] = true
+		removed[pkg.Path] = true
+		gopath[filepath.Clean(pkg.Dir)] = pkg.Gopath
+	}
+
+	// Clear the status set from the references of other packages.
+	for _, pkg := range ctx.Package {
+		switch pkg.Status.Presence {
+		case PresenceUnused, PresenceTree:
+			pkg.Status.Presence = PresenceFound
+			if pkg.Status.Location == LocationLocal {
+				continue
+			}
+			for _, exclude := range ctx.excludePackage {
+				if pkg.Path == exclude || strings.HasPrefix(pkg.Path, exclude+"/") {
+					pkg.Status.Presence = PresenceExcluded
+				}
+			}
+		}
+	}
+
+	rootdir, err := filepath.EvalSymlinks(ctx.RootDir)
+	if err != nil {
+		return err
+	}
+	for dir := range dirs {
+		delete(ctx.dirStamp, dir)
+		if !filepath.HasPrefixDir(dir, ctx.RootDir) {
+			// A package outside the project is only added when imported.
+			if g, found := gopath[dir]; found {
+				err = ctx.readPackageDir(dir, g)
+			}
+		} else {
+			err = ctx.readPackageDir(dir, ctx.RootGopath)
+			if err == nil {
+				err = ctx.walkNewDirs(rootdir, dir)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Resolve again the imports of removed packages, they may now be
+	// found in another location.
+	for _, pkg := range ctx.Package {
+		if pkg.Status.Presence == PresenceExcluded {
+			continue
+		}
+		for _, f := range pkg.Files {
+			for _, imp := range f.Imports {
+				if !removed[imp] {
+					continue
+				}
+				_, err = ctx.addSingleImport(pkg.Dir, imp, pkg.IncludeTree)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return ctx.determinePackageStatus()
+}
+
+// readPackageDir adds the files in dir, but not sub-folders.
+func (ctx *Context) readPackageDir(dir, gopath string) error {
+	ctx.readDir(dir)
+	df, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	info, err := df.Readdir(-1)
+	df.Close()
+	if err != nil {
+		return err
+	}
+	for _, fi := range info {
+		if fi.IsDir() {
+			continue
+		}
+		switch fi.Name()[0] {
+		case '.', '_':
+			continue
+		}
+		_, err = ctx.addFileImports(filepath.Join(dir, fi.Name()), gopath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkNewDirs adds the packages in the sub-folders of dir that were not
+// read before, such as a new package folder.
+func (ctx *Context) walkNewDirs(rootdir, dir string) error {
+	df, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	info, err := df.Readdir(-1)
+	df.Close()
+	if err != nil {
+		return err
+	}
+	for _, fi := range info {
+		if !fi.IsDir() {
+			continue
+		}
+		sub := filepath.Join(dir, fi.Name())
+		if _, found := ctx.dirStamp[sub]; found {
+			continue
+		}
+		err = ctx.walkPackages(rootdir, strings.Replace(sub, ctx.RootDir, rootdir, 1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
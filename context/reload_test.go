@@ -0,0 +1,117 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestReload(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	list(g, c, "before", `
+ e  co2/pk1 < ["co1/pk1"]
+ l  co1/pk1 < []
+ s  strings < ["co1/pk1"]
+`)
+	co2 := c.Package["co2/pk1"]
+	b := filepath.Join(g.Current(), "pk1", "b.go")
+	parsedB := c.parsed[b].f
+	if parsedB == nil {
+		t.Fatal("b.go not parsed")
+	}
+	changed, err := c.Reload()
+	g.Check(err)
+	if len(changed) != 0 {
+		t.Fatalf("changed before edit %q", changed)
+	}
+
+	// Edit a file and add another, as a checkout would.
+	a := filepath.Join(g.Current(), "pk1", "a.go")
+	writeFile(t, a, "package pk1\n")
+	later := time.Now().Add(time.Hour)
+	g.Check(os.Chtimes(a, later, later))
+	g.Setup("co1/pk2",
+		gt.File("a.go", "co2/pk1"),
+	)
+	changed, err = c.Reload()
+	g.Check(err)
+	expected := []string{g.Current(), a}
+	if len(changed) != len(expected) || changed[0] != expected[0] || changed[1] != expected[1] {
+		t.Fatalf("got changed %q, want %q", changed, expected)
+	}
+	if c.dirty || c.Package["co2/pk1"] != co2 {
+		t.Error("unchanged packages loaded again")
+	}
+	list(g, c, "after", `
+ e  co2/pk1 < ["co1/pk2"]
+ l  co1/pk1 < []
+ l  co1/pk2 < []
+ s  strings < ["co1/pk1"]
+`)
+	if c.parsed[b].f != parsedB {
+		t.Error("unchanged b.go parsed again")
+	}
+
+	// A changed vendor file is read again.
+	g.Check(os.MkdirAll(filepath.Join(g.Current(), "vendor"), 0777))
+	writeFile(t, filepath.Join(g.Current(), "vendor", "vendor.json"), `{"ignore": "test"}`)
+	changed, err = c.Reload()
+	g.Check(err)
+	if len(changed) != 1 || c.VendorFile.Ignore != "test" {
+		t.Fatalf("vendor file not read again, changed %q", changed)
+	}
+}
+
+func TestReloadRemovedVendor(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co1/vendor/co2/pk1",
+		gt.File("a.go", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	list(g, c, "before", `
+ v  co1/vendor/co2/pk1 [co2/pk1] < ["co1/pk1"]
+ e  co3/pk1 < ["co1/vendor/co2/pk1"]
+ l  co1/pk1 < []
+`)
+	// The import is found in GOPATH once the vendor copy is removed.
+	g.Check(os.RemoveAll(filepath.Join(g.Current(), "vendor", "co2", "pk1")))
+	_, err := c.Reload()
+	g.Check(err)
+	if c.dirty {
+		t.Error("all packages loaded again")
+	}
+	list(g, c, "after", `
+ e  co2/pk1 < ["co1/pk1"]
+ l  co1/pk1 < []
+`)
+}
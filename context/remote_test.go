@@ -6,6 +6,9 @@ package context
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/kardianos/govendor/internal/gt"
@@ -49,6 +52,7 @@ func TestFetchSimple(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"origin": "`+remoteOrigin+`",
 			"path": "co2/pk1",
 			"revision": "`+commitRev+`",
@@ -136,6 +140,7 @@ func TestUpdateOrigin(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "uL2Z45bjLtrTugQclzHmwbmiTb4=",
+			"checksumSHA256": "yKoA9+dqkWKzeuy+IFX2g114ka1soDlegqwmk11IeDc=",
 			"origin": "`+remoteOrigin+`",
 			"path": "co2/pk1",
 			"revision": "`+commitRev+`",
@@ -187,6 +192,7 @@ func TestFetchSub(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "n1Dr4feYQIIdZiRxoB4ftixPMYw=",
+			"checksumSHA256": "c+nO3i+m/KqYYM7hrVMJLujx1BweeebyoC5yB5h7br0=",
 			"origin": "`+remotePkg+`",
 			"path": "remote/co2/pk1",
 			"revision": "`+commitRev1+`",
@@ -194,6 +200,7 @@ func TestFetchSub(t *testing.T) {
 		},
 		{
 			"checksumSHA1": "opE9eCYYfMt97gF4AbJMCc3ftwY=",
+			"checksumSHA256": "ae+zaBJtGBlaT8lSbXRZo82YKLRDlqxHZAb5ldb67Jo=",
 			"origin": "`+remotePkg+`/pk2",
 			"path": "remote/co2/pk1/pk2",
 			"revision": "`+commitRev1+`",
@@ -236,6 +243,7 @@ func TestFetchAgain(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "x",
+			"checksumSHA256": "x",
 			"path": "`+remotePkg+`",
 			"revision": "`+commitRev1+`",
 			"revisionTime": "`+commitTime1+`"
@@ -243,7 +251,7 @@ func TestFetchAgain(t *testing.T) {
 	],
 	"rootPath": "co1"
 }
-`, `"checksumSHA1":`)
+`, `"checksumSHA1":`, `"checksumSHA256":`)
 
 	g.Setup("remote/co2/pk1",
 		gt.File("a.go", "bytes", "strings"),
@@ -263,6 +271,7 @@ func TestFetchAgain(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "x",
+			"checksumSHA256": "x",
 			"path": "`+remotePkg+`",
 			"revision": "`+commitRev2+`",
 			"revisionTime": "`+commitTime2+`"
@@ -270,7 +279,7 @@ func TestFetchAgain(t *testing.T) {
 	],
 	"rootPath": "co1"
 }
-`, `"checksumSHA1":`)
+`, `"checksumSHA1":`, `"checksumSHA256":`)
 
 	list(g, c, "2", `
  v  co1/vendor/`+remotePkg+` [`+remotePkg+`] < ["co1/pk1"]
@@ -336,3 +345,214 @@ s  strings < ["`+root+`B/C"]
 
 	`)
 }
+
+func TestFetchKeepGoing(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.Setup("remote/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	remote.Setup().Commit()
+	g.In("remote/co3")
+	commitRev, _ := remote.Setup().Commit()
+
+	remotePkg2 := remote.HttpAddr() + "/remote/co2/pk1"
+	remotePkg3 := remote.HttpAddr() + "/remote/co3/pk1"
+	g.Setup("co1/pk1",
+		gt.File("a.go", remotePkg2, remotePkg3),
+	)
+	g.In("co1")
+	c := ctx(g)
+	c.KeepGoing = true
+
+	// No such version, fails after the repo is downloaded.
+	g.Check(c.ModifyImport(pkg(remotePkg2+"@v9.9.9"), Fetch))
+	g.Check(c.ModifyImport(pkg(remotePkg3), Fetch))
+	err := c.Alter()
+	rem, is := err.(ErrRemoteFailures)
+	if !is {
+		t.Fatalf("expected remote failures, got %v", err)
+	}
+	if len(rem) != 1 || rem[0].Path != remotePkg2 {
+		t.Fatalf("unexpected failures %v", rem)
+	}
+	g.Check(c.WriteVendorFile())
+
+	vp := c.VendorFilePackagePath(remotePkg3)
+	if vp == nil || vp.Revision != commitRev || len(vp.ChecksumSHA1) == 0 {
+		t.Fatalf("package %q not fetched: %+v", remotePkg3, vp)
+	}
+	vendorFilePath := filepath.Join(g.Current(), "vendor", "vendor.json")
+	vf, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	if bytes.Contains(vf, []byte(remotePkg2)) {
+		t.Fatalf("failed package %q added to the vendor file\n%s", remotePkg2, vf)
+	}
+
+	// A failed package already in the vendor file is left unchanged.
+	c = ctx(g)
+	g.Check(c.ModifyImport(pkg(remotePkg2), Fetch))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+	before, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+
+	c = ctx(g)
+	c.KeepGoing = true
+	g.Check(c.ModifyImport(pkg(remotePkg2+"@v9.9.9"), Fetch))
+	g.Check(c.ModifyImport(pkg(remotePkg3), Fetch))
+	if _, is := c.Alter().(ErrRemoteFailures); !is {
+		t.Fatal("expected remote failures")
+	}
+	g.Check(c.WriteVendorFile())
+	after, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	if !bytes.Equal(before, after) {
+		t.Fatalf("vendor file changed\n%s", after)
+	}
+}
+
+func TestFetchRollback(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("remote/co2/pk1",
+		gt.File("a.go", "bytes"),
+	)
+	g.Setup("remote/co3/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("remote")
+	remote := gt.NewHttpHandler(g, "git")
+
+	g.In("remote/co2")
+	remote.Setup().Commit()
+	g.In("remote/co3")
+	co3 := remote.Setup()
+	commitRev, _ := co3.Commit()
+
+	remotePkg2 := remote.HttpAddr() + "/remote/co2/pk1"
+	remotePkg3 := remote.HttpAddr() + "/remote/co3/pk1"
+	g.Setup("co1/pk1",
+		gt.File("a.go", remotePkg2, remotePkg3),
+	)
+	g.In("co1")
+	c := ctx(g)
+
+	g.Check(c.ModifyImport(pkg(remotePkg3), Fetch))
+	g.Check(c.Alter())
+
+	vendorFilePath := filepath.Join(g.Current(), "vendor", "vendor.json")
+	vendoredPath := filepath.Join(g.Current(), "vendor", filepath.FromSlash(remotePkg3), "a.go")
+	beforeVendorFile, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	beforeVendored, err := ioutil.ReadFile(vendoredPath)
+	g.Check(err)
+
+	g.Setup("remote/co3/pk1",
+		gt.File("a.go", "strings", "bytes"),
+	)
+	g.In("remote/co3")
+	co3.Commit()
+
+	// The first fetch succeeds, the second fails after it.
+	g.In("co1")
+	g.Check(c.ModifyImport(pkg(remotePkg3), Fetch))
+	g.Check(c.ModifyImport(pkg(remotePkg2+"@v9.9.9"), Fetch))
+	if err = c.Alter(); err == nil {
+		t.Fatal("expected fetch to fail")
+	}
+
+	afterVendorFile, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	if !bytes.Equal(beforeVendorFile, afterVendorFile) {
+		t.Fatalf("vendor file changed\n%s", afterVendorFile)
+	}
+	afterVendored, err := ioutil.ReadFile(vendoredPath)
+	g.Check(err)
+	if !bytes.Equal(beforeVendored, afterVendored) {
+		t.Fatalf("vendored file changed\n%s", afterVendored)
+	}
+	staged, err := filepath.Glob(filepath.Join(g.Current(), ".govendor-stage-*"))
+	g.Check(err)
+	if len(staged) != 0 {
+		t.Fatalf("stage not removed %q", staged)
+	}
+	if vp := c.VendorFilePackagePath(remotePkg3); vp == nil || vp.Revision != commitRev {
+		t.Fatalf("vendor file package not restored %+v", vp)
+	}
+}
+
+func TestAlterNoChange(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	vendorFilePath := filepath.Join(g.Current(), "vendor", "vendor.json")
+	g.Check(os.MkdirAll(filepath.Dir(vendorFilePath), 0777))
+	// Not in the written format, so any write changes it.
+	before := []byte(`{"comment": "keep"}`)
+	g.Check(ioutil.WriteFile(vendorFilePath, before, 0666))
+	c := ctx(g)
+
+	g.Check(c.ModifyStatus(StatusGroup{Status: []Status{{Location: LocationExternal}}}, Add))
+	g.Check(c.Alter())
+	after, err := ioutil.ReadFile(vendorFilePath)
+	g.Check(err)
+	if !bytes.Equal(before, after) {
+		t.Fatalf("vendor file written\n%s", after)
+	}
+}
+
+func TestRecoverStage(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	c := ctx(g)
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+
+	root := filepath.Join(g.Current(), "vendor")
+	stage := filepath.Join(g.Current(), ".govendor-stage-1")
+	g.Check(os.Mkdir(stage, 0777))
+
+	// Stopped after the vendor folder was moved aside.
+	g.Check(os.Rename(root, stage+".old"))
+	c = ctx(g)
+	if vp := c.VendorFilePackagePath("co2/pk1"); vp == nil {
+		t.Fatal("vendor file not restored")
+	}
+	staged, err := filepath.Glob(filepath.Join(g.Current(), ".govendor-stage-*"))
+	g.Check(err)
+	if len(staged) != 0 {
+		t.Fatalf("stage not removed %q", staged)
+	}
+
+	// Stopped after the stage replaced the vendor folder.
+	g.Check(os.Mkdir(stage+".old", 0777))
+	c = ctx(g)
+	if _, err = os.Stat(stage + ".old"); !os.IsNotExist(err) {
+		t.Fatalf("old vendor folder not removed: %v", err)
+	}
+	if vp := c.VendorFilePackagePath("co2/pk1"); vp == nil {
+		t.Fatal("vendor file package missing")
+	}
+}
@@ -5,6 +5,7 @@
 package context
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -19,18 +20,6 @@ import (
 	"github.com/kardianos/govendor/pkgspec"
 )
 
-var knownOS = make(map[string]bool)
-var knownArch = make(map[string]bool)
-
-func init() {
-	for _, v := range strings.Fields(goosList) {
-		knownOS[v] = true
-	}
-	for _, v := range strings.Fields(goarchList) {
-		knownArch[v] = true
-	}
-}
-
 // loadPackage sets up the context with package information and
 // is called before any initial operation is performed.
 func (ctx *Context) loadPackage() error {
@@ -38,13 +27,25 @@ func (ctx *Context) loadPackage() error {
 	ctx.dirty = false
 	ctx.statusCache = nil
 	ctx.Package = make(map[string]*Package, len(ctx.Package))
+	ctx.dirStamp = nil
 	// We following the root symlink only in case the root of the repo is symlinked into the GOPATH
 	// This could happen during on some CI that didn't checkout into the GOPATH
 	rootdir, err := filepath.EvalSymlinks(ctx.RootDir)
 	if err != nil {
 		return err
 	}
-	err = filepath.Walk(rootdir, func(path string, info os.FileInfo, err error) error {
+	err = ctx.walkPackages(rootdir, rootdir)
+	if err != nil {
+		return err
+	}
+	// Finally, set any unset status.
+	return ctx.determinePackageStatus()
+}
+
+// walkPackages adds the packages in dir and its sub-folders. The rootdir
+// is the project root with any symlinks followed.
+func (ctx *Context) walkPackages(rootdir, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if info == nil {
 			return err
 		}
@@ -58,6 +59,7 @@ func (ctx *Context) loadPackage() error {
 		name := info.Name()
 		// Still go into "_workspace" to aid godep migration.
 		if name == "_workspace" {
+			ctx.readDir(strings.Replace(path, rootdir, ctx.RootDir, 1))
 			return nil
 		}
 		switch name[0] {
@@ -68,13 +70,9 @@ func (ctx *Context) loadPackage() error {
 		case "testdata", "node_modules":
 			return filepath.SkipDir
 		}
+		ctx.readDir(strings.Replace(path, rootdir, ctx.RootDir, 1))
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	// Finally, set any unset status.
-	return ctx.determinePackageStatus()
 }
 
 func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imports []string, err error) {
@@ -120,8 +118,30 @@ func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imp
 		tags.AddFileTag(l[n-1])
 	}
 
+	// A "//go:build" line before the package clause replaces any
+	// "// +build" lines. An invalid expression is not used.
 	const buildPrefix = "// +build "
+	const goBuildPrefix = "//go:build "
+	hasGoBuild := false
+	for _, cc := range f.Comments {
+		if cc.Pos() > f.Package {
+			break
+		}
+		for _, c := range cc.List {
+			if strings.HasPrefix(c.Text, goBuildPrefix) {
+				err := tags.AddBuildExpr(strings.TrimPrefix(c.Text, goBuildPrefix))
+				if err != nil {
+					fmt.Fprintf(ctx, "%s: %v\n", pathname, err)
+					continue
+				}
+				hasGoBuild = true
+			}
+		}
+	}
 	for _, cc := range f.Comments {
+		if hasGoBuild {
+			break
+		}
 		for _, c := range cc.List {
 			if strings.HasPrefix(c.Text, buildPrefix) {
 				text := strings.TrimPrefix(c.Text, buildPrefix)
@@ -147,9 +167,7 @@ func (ctx *Context) getFileTags(pathname string, f *ast.File) (tags *TagSet, imp
 // addFileImports is called from loadPackage and resolveUnknown.
 func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 	dir, filenameExt := filepath.Split(pathname)
-	importPath := pathos.FileTrimPrefix(dir, gopath)
-	importPath = pathos.SlashToImportPath(importPath)
-	importPath = strings.Trim(importPath, "/")
+	importPath := ctx.dirImport(gopath, dir)
 
 	if !strings.HasSuffix(pathname, ".go") {
 		return nil, nil
@@ -170,8 +188,7 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 			}
 		}
 	}
-	// Ignore error here and continue on best effort.
-	f, _ := parser.ParseFile(token.NewFileSet(), pathname, nil, parser.ImportsOnly|parser.ParseComments)
+	f := ctx.parseImports(pathname)
 	if f == nil {
 		return nil, nil
 	}
@@ -205,6 +222,11 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 		pkg = ctx.setPackage(dir, importPath, importPath, gopath, status)
 		ctx.Package[importPath] = pkg
 	}
+	if ctx.platformExcluded(tags) {
+		pkg.ignoreFile = append(pkg.ignoreFile, filenameExt)
+		pkg.platformExcluded = append(pkg.platformExcluded, filenameExt)
+		return pkg, nil
+	}
 	if pkg.Status.Location != LocationLocal {
 		if tags.IgnoreItem(ctx.ignoreTag...) {
 			pkg.ignoreFile = append(pkg.ignoreFile, filenameExt)
@@ -243,6 +265,13 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 	}
 
 	// Record any import comment for file.
+	pf.ImportComment = importComment(f)
+
+	return pkg, nil
+}
+
+// importComment returns the import comment of the package clause.
+func importComment(f *ast.File) string {
 	var ic *ast.Comment
 	if f.Name != nil {
 		pos := f.Name.Pos()
@@ -257,18 +286,19 @@ func (ctx *Context) addFileImports(pathname, gopath string) (*Package, error) {
 			}
 		}
 	}
-	if ic != nil {
-		// If it starts with the import text, assume it is the import comment.
-		if index := strings.Index(ic.Text, " import "); index > 0 && index < 5 {
-			q := strings.TrimSpace(ic.Text[index+len(" import "):])
-			pf.ImportComment, err = strconv.Unquote(q)
-			if err != nil {
-				pf.ImportComment = q
-			}
-		}
+	if ic == nil {
+		return ""
 	}
-
-	return pkg, nil
+	// If it starts with the import text, assume it is the import comment.
+	index := strings.Index(ic.Text, " import ")
+	if index <= 0 || index >= 5 {
+		return ""
+	}
+	q := strings.TrimSpace(ic.Text[index+len(" import "):])
+	if s, err := strconv.Unquote(q); err == nil {
+		return s
+	}
+	return q
 }
 
 func (ctx *Context) setPackage(dir, canonical, local, gopath string, status Status) *Package {
@@ -351,6 +381,13 @@ func (ctx *Context) addSingleImport(pkgInDir, imp string, tree bool) (*Package,
 			return nil, nil
 		}
 	}
+	if std := ctx.stdImport(pkgInDir, imp); len(std) > 0 {
+		return ctx.setPackage(filepath.Join(ctx.Goroot, pathos.SlashToFilepath(std)), imp, imp, ctx.Goroot, Status{
+			Type:     TypePackage,
+			Location: LocationStandard,
+			Presence: PresenceFound,
+		}), nil
+	}
 	dir, gopath, err := ctx.findImportDir(pkgInDir, imp)
 	if err != nil {
 		if _, is := err.(ErrNotInGOPATH); is {
@@ -383,6 +420,7 @@ func (ctx *Context) addSingleImport(pkgInDir, imp string, tree bool) (*Package,
 			Presence: PresenceFound,
 		}), nil
 	}
+	ctx.readDir(dir)
 	df, err := os.Open(dir)
 	if err != nil {
 		return nil, err
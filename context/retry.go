@@ -0,0 +1,81 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	gocontext "context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Retry controls how failed network operations are retried.
+type Retry struct {
+	Attempts int           // Number of attempts, values less than one are treated as one.
+	Delay    time.Duration // Delay before the first retry, doubled for each following retry.
+	MaxDelay time.Duration // Upper limit of the delay, zero for no limit.
+}
+
+// DefaultRetry is the retry policy of a new Context.
+var DefaultRetry = Retry{
+	Attempts: 3,
+	Delay:    time.Second,
+	MaxDelay: 30 * time.Second,
+}
+
+// permanentError is returned from an operation that will fail again if retried.
+type permanentError struct {
+	error
+}
+
+// do runs f until it succeeds, returns a permanentError or the attempts run
+// out. Each retry is noted in the logger.
+func (r Retry) do(logger io.Writer, name string, f func() error) error {
+	return r.doInterrupt(nil, logger, name, f)
+}
+
+// doInterrupt is do that stops retrying once interrupt is done.
+// A nil interrupt is never done.
+func (r Retry) doInterrupt(interrupt gocontext.Context, logger io.Writer, name string, f func() error) error {
+	var done <-chan struct{}
+	if interrupt != nil {
+		done = interrupt.Done()
+	}
+	delay := r.Delay
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		if perm, is := err.(permanentError); is {
+			return perm.error
+		}
+		if interrupt != nil && interrupt.Err() != nil {
+			return ErrInterrupted{interrupt.Err()}
+		}
+		if attempt >= r.Attempts {
+			return err
+		}
+		if logger != nil {
+			fmt.Fprintf(logger, "Retry %s in %v (attempt %d of %d): %v\n", name, delay, attempt+1, r.Attempts, err)
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-done:
+			t.Stop()
+			return ErrInterrupted{interrupt.Err()}
+		}
+		delay *= 2
+		if r.MaxDelay > 0 && delay > r.MaxDelay {
+			delay = r.MaxDelay
+		}
+	}
+}
+
+// retry runs f with the context retry policy until the Interrupt is done.
+func (ctx *Context) retry(name string, f func() error) error {
+	return ctx.Retry.doInterrupt(ctx.Interrupt, ctx, name, f)
+}
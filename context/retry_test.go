@@ -0,0 +1,58 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	r := Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	errFail := errors.New("fail")
+
+	list := []struct {
+		Name    string
+		FailFor int // Number of attempts to fail, -1 to always fail.
+		Perm    bool
+		Calls   int
+		Err     bool
+		Retries int // Number of retry log lines.
+	}{
+		{Name: "ok", FailFor: 0, Calls: 1},
+		{Name: "recover", FailFor: 2, Calls: 3, Retries: 2},
+		{Name: "exhaust", FailFor: -1, Calls: 3, Err: true, Retries: 2},
+		{Name: "permanent", FailFor: -1, Perm: true, Calls: 1, Err: true},
+	}
+	for _, item := range list {
+		buf := &bytes.Buffer{}
+		calls := 0
+		err := r.do(buf, item.Name, func() error {
+			calls++
+			if item.FailFor >= 0 && calls > item.FailFor {
+				return nil
+			}
+			if item.Perm {
+				return permanentError{errFail}
+			}
+			return errFail
+		})
+		if calls != item.Calls {
+			t.Errorf("%s: got %d calls, want %d", item.Name, calls, item.Calls)
+		}
+		if (err != nil) != item.Err {
+			t.Errorf("%s: unexpected error %v", item.Name, err)
+		}
+		if err != nil && err != errFail {
+			t.Errorf("%s: got error %#v, want the original error", item.Name, err)
+		}
+		if got := strings.Count(buf.String(), "Retry "); got != item.Retries {
+			t.Errorf("%s: got %d retries logged, want %d", item.Name, got, item.Retries)
+		}
+	}
+}
@@ -173,16 +173,21 @@ func (ctx *Context) rewrite() error {
 		if err != nil {
 			return err
 		}
+		span := ctx.startEvent(Event{Kind: EventRewrite, Package: fileInfo.Package.Path, Path: fileInfo.Path})
 		w, err := safefile.Create(fileInfo.Path, fi.Mode())
 		if err != nil {
+			span.finish(0, err)
 			return err
 		}
-		err = goprint.Fprint(w, fileset, f)
+		cw := &countWriter{w: w}
+		err = goprint.Fprint(cw, fileset, f)
 		if err != nil {
 			w.Close()
+			span.finish(cw.n, err)
 			return err
 		}
 		err = w.Commit()
+		span.finish(cw.n, err)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,153 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/kardianos/govendor/internal/pathos"
+)
+
+// setOutsideRoot sets up a project root that isn't in any GOPATH.
+// The root folder is used as the folder of the root import path, which
+// is taken from rootImport, the vendor file "rootPath", or the import
+// comment of a package file in the root folder.
+func (ctx *Context) setOutsideRoot(rootImport string, gopathList []string) error {
+	root := filepath.Clean(ctx.RootDir)
+	if len(rootImport) == 0 {
+		rootImport = findRootImport(root, ctx.VendorFilePath)
+	}
+	rootImport = strings.Trim(path.Clean(pathos.SlashToImportPath(rootImport)), "/")
+	switch {
+	case len(rootImport) == 0, rootImport == ".", strings.HasPrefix(rootImport, "../"):
+		return ErrNoRootImport{ctx.RootDir}
+	}
+	dprintf("CTX outside GOPATH: %s as %s\n", root, rootImport)
+
+	ctx.outsideGopath = true
+	ctx.RootDir = root
+	ctx.RootImportPath = rootImport
+	ctx.RootGopath = root + string(filepath.Separator)
+
+	// The project packages are found before any in GOPATH.
+	list := make([]string, 0, len(ctx.GopathList)+1)
+	list = append(list, ctx.GopathList[0], ctx.RootGopath)
+	ctx.GopathList = append(list, ctx.GopathList[1:]...)
+
+	// Share the cache with the first GOPATH when there is one.
+	if len(gopathList) > 0 {
+		ctx.cacheRoot = filepath.Join(gopathList[0], ".cache", "govendor")
+	} else if dir := UserCacheDir(); len(dir) > 0 {
+		ctx.cacheRoot = dir
+	} else {
+		ctx.cacheRoot = filepath.Join(root, ".cache", "govendor")
+	}
+	return nil
+}
+
+// UserCacheDir returns the per-user govendor cache folder, or an empty
+// string if unknown. It is "%LocalAppData%\govendor" on Windows and
+// "$HOME/.cache/govendor" elsewhere, or "$XDG_CACHE_HOME/govendor" if set.
+func UserCacheDir() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); len(dir) > 0 {
+			return filepath.Join(dir, "govendor")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, "govendor")
+	}
+	if home := homeDir(); len(home) > 0 {
+		return filepath.Join(home, ".cache", "govendor")
+	}
+	return ""
+}
+
+// findRootImport returns the import path recorded in the vendor file or
+// in the import comment of a package file in root.
+func findRootImport(root, vendorFilePath string) string {
+	vf, err := readVendorFile("", vendorFilePath)
+	if err == nil && len(vf.RootPath) > 0 {
+		return vf.RootPath
+	}
+	dir, err := os.Open(root)
+	if err != nil {
+		return ""
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return ""
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		switch name[0] {
+		case '.', '_':
+			continue
+		}
+		f, _ := parser.ParseFile(token.NewFileSet(), filepath.Join(root, name), nil, parser.PackageClauseOnly|parser.ParseComments)
+		if f == nil {
+			continue
+		}
+		if ic := importComment(f); len(ic) > 0 {
+			return ic
+		}
+	}
+	return ""
+}
+
+// importDir returns the folder of the import path in gopath. It returns
+// an empty string if gopath is the root of a project outside GOPATH and the
+// import path isn't in the project.
+func (ctx *Context) importDir(gopath, importPath string) string {
+	if !ctx.outsideGopath || !pathos.FileStringEquals(gopath, ctx.RootGopath) {
+		return filepath.Join(gopath, importPath)
+	}
+	if importPath == ctx.RootImportPath {
+		return ctx.RootDir
+	}
+	if !strings.HasPrefix(importPath, ctx.RootImportPath+"/") {
+		return ""
+	}
+	return filepath.Join(ctx.RootDir, pathos.SlashToFilepath(importPath[len(ctx.RootImportPath)+1:]))
+}
+
+// dirImport returns the import path of the folder dir in gopath.
+func (ctx *Context) dirImport(gopath, dir string) string {
+	importPath := pathos.FileTrimPrefix(dir, gopath)
+	importPath = pathos.SlashToImportPath(importPath)
+	importPath = strings.Trim(importPath, "/")
+	if ctx.outsideGopath && pathos.FileStringEquals(gopath, ctx.RootGopath) {
+		return path.Join(ctx.RootImportPath, importPath)
+	}
+	return importPath
+}
+
+// LocalDir returns the folder of a package by its local import path.
+func (ctx *Context) LocalDir(local string) string {
+	if dir := ctx.importDir(ctx.RootGopath, local); len(dir) > 0 {
+		return dir
+	}
+	if dir, _, err := ctx.findImportDir("", local); err == nil {
+		return dir
+	}
+	return filepath.Join(ctx.RootGopath, local)
+}
+
+// DirImportPath returns the import path of a folder in the project.
+func (ctx *Context) DirImportPath(dir string) string {
+	return ctx.dirImport(ctx.RootGopath, dir)
+}
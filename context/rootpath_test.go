@@ -0,0 +1,77 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestOutsideGopath(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+
+	root, err := ioutil.TempDir("", "govendor-outside-")
+	g.Check(err)
+	defer os.RemoveAll(root)
+	g.Check(os.MkdirAll(filepath.Join(root, "sub"), 0777))
+	g.Check(os.MkdirAll(filepath.Join(root, "vendor"), 0777))
+	writeFile(t, filepath.Join(root, "main.go"), "package main // import \"example.com/proj\"\n\nimport (\n\t_ \"co2/pk1\"\n\t_ \"example.com/proj/sub\"\n)\n")
+	writeFile(t, filepath.Join(root, "sub", "s.go"), "package sub\n")
+
+	c, err := NewContext(root, relVendorFile, "vendor", false)
+	g.Check(err)
+	if c.RootImportPath != "example.com/proj" {
+		t.Fatalf("unexpected root import path %q", c.RootImportPath)
+	}
+	g.Check(c.ModifyImport(pkg("co2/pk1"), Add))
+	g.Check(c.Alter())
+	g.Check(c.WriteVendorFile())
+
+	if _, err := os.Stat(filepath.Join(root, "vendor", "co2", "pk1", "a.go")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(c.CacheRoot(), root) {
+		t.Errorf("cache %q is in the project", c.CacheRoot())
+	}
+
+	// The vendor file records the root import path for later runs.
+	g.Check(os.Remove(filepath.Join(root, "main.go")))
+	writeFile(t, filepath.Join(root, "main.go"), "package main\n\nimport _ \"co2/pk1\"\n")
+	c, err = NewContext(root, relVendorFile, "vendor", false)
+	g.Check(err)
+	list(g, c, "outside", `
+v  example.com/proj/vendor/co2/pk1 [co2/pk1] < ["example.com/proj"]
+pl  example.com/proj < []
+l  example.com/proj/sub < []
+s  strings < ["example.com/proj/vendor/co2/pk1"]
+`)
+	if dir := c.LocalDir("example.com/proj/vendor/co2/pk1"); dir != filepath.Join(root, "vendor", "co2", "pk1") {
+		t.Errorf("unexpected local dir %q", dir)
+	}
+
+	// Without an import path the folder can't be used.
+	other, err := ioutil.TempDir("", "govendor-outside-")
+	g.Check(err)
+	defer os.RemoveAll(other)
+	_, err = NewContext(other, relVendorFile, "vendor", false)
+	if _, is := err.(ErrNoRootImport); !is {
+		t.Fatalf("expected ErrNoRootImport, got %v", err)
+	}
+	c, err = newContext(other, relVendorFile, "vendor", "example.com/other/", false)
+	g.Check(err)
+	if c.RootImportPath != "example.com/other" {
+		t.Fatalf("unexpected root import path %q", c.RootImportPath)
+	}
+}
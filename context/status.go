@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/kardianos/govendor/pkgspec"
 )
@@ -185,6 +186,11 @@ type StatusItem struct {
 	VersionExact string
 	Local        string
 	ImportedBy   []*Package
+
+	// PlatformExcluded are the package files that can't build for any
+	// target platform and are not used. For a vendor package these are
+	// the files that were not copied.
+	PlatformExcluded []string
 }
 
 func (li StatusItem) String() string {
@@ -219,9 +225,13 @@ func (ctx *Context) updateStatusCache() error {
 	for _, pkg := range ctx.Package {
 		version := ""
 		versionExact := ""
+		excluded := pkg.platformExcluded
 		if vp := ctx.VendorFilePackagePath(pkg.Path); vp != nil {
 			version = vp.Version
 			versionExact = vp.VersionExact
+			if pkg.Status.Location == LocationVendor {
+				excluded = append(strings.Fields(vp.PlatformExcluded), excluded...)
+			}
 		}
 
 		origin := ""
@@ -238,6 +248,8 @@ func (ctx *Context) updateStatusCache() error {
 			Local:        pkg.Local,
 			VersionExact: versionExact,
 			ImportedBy:   make([]*Package, 0, len(pkg.referenced)),
+
+			PlatformExcluded: excluded,
 		}
 		for _, ref := range pkg.referenced {
 			li.ImportedBy = append(li.ImportedBy, ref)
@@ -0,0 +1,113 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// stdIndex is the set of standard library import paths of a toolchain.
+// Packages vendored in GOROOT are listed with a "vendor/" prefix.
+type stdIndex map[string]bool
+
+var (
+	stdIndexLock sync.Mutex
+	stdIndexList = make(map[string]stdIndex) // By GOROOT and Go version.
+)
+
+// stdIndex returns the standard library index of the context toolchain.
+// It is loaded once for each GOROOT and Go version, from the disk cache,
+// "go list std", or a walk of GOROOT.
+func (ctx *Context) stdIndex() stdIndex {
+	key := ctx.Goroot + "@" + ctx.goVersion
+	stdIndexLock.Lock()
+	defer stdIndexLock.Unlock()
+	if index, found := stdIndexList[key]; found {
+		return index
+	}
+	list := ctx.loadStdList()
+	index := make(stdIndex, len(list))
+	for _, p := range list {
+		index[p] = true
+	}
+	stdIndexList[key] = index
+	return index
+}
+
+func (ctx *Context) loadStdList() []string {
+	cacheFile := ""
+	if len(ctx.goVersion) > 0 {
+		cacheFile = filepath.Join(ctx.CacheRoot(), ".dist", cacheFileName(ctx.goVersion)+".std")
+		if b, err := ioutil.ReadFile(cacheFile); err == nil {
+			return strings.Fields(string(b))
+		}
+	}
+	var list []string
+	out, err := exec.Command("go", "list", "std").Output()
+	if err == nil {
+		list = strings.Fields(string(out))
+	} else {
+		dprintf("go list std: %v\n", err)
+		list = walkGoroot(ctx.Goroot)
+	}
+	if len(cacheFile) > 0 && len(list) > 0 {
+		if os.MkdirAll(filepath.Dir(cacheFile), 0700) == nil {
+			ioutil.WriteFile(cacheFile, []byte(strings.Join(list, "\n")+"\n"), 0600)
+		}
+	}
+	return list
+}
+
+// walkGoroot lists the folders of goroot, the GOROOT "src" folder, with Go
+// files, except for commands and test data.
+func walkGoroot(goroot string) []string {
+	var list []string
+	filepath.Walk(goroot, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(goroot, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		name := info.Name()
+		if p != goroot && (name[0] == '.' || name[0] == '_' || name == "testdata" || rel == "cmd") {
+			return filepath.SkipDir
+		}
+		if has, _ := hasGoFileInFolder(p); has && p != goroot {
+			list = append(list, rel)
+		}
+		return nil
+	})
+	sort.Strings(list)
+	return list
+}
+
+// stdImport returns the standard library path of the import from the
+// folder dir, or an empty string if it isn't in the standard library.
+// A standard library package may import the packages vendored in GOROOT.
+func (ctx *Context) stdImport(dir, importPath string) string {
+	switch importPath {
+	case "builtin", "unsafe", "C":
+		return importPath
+	}
+	index := ctx.stdIndex()
+	if index[importPath] {
+		return importPath
+	}
+	if len(dir) > 0 && (dir == ctx.Goroot || strings.HasPrefix(dir, ctx.Goroot+string(filepath.Separator))) {
+		if vendored := "vendor/" + importPath; index[vendored] {
+			return vendored
+		}
+	}
+	return ""
+}
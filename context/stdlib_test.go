@@ -0,0 +1,97 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStdIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-std-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	goroot := filepath.Join(dir, "goroot", "src")
+	for _, p := range []string{"fmt", "net/http", "vendor/golang.org/x/net/idna", "cmd/go", "fmt/testdata", "_old"} {
+		d := filepath.Join(goroot, filepath.FromSlash(p))
+		if err := os.MkdirAll(d, 0777); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(d, "a.go"), "package a\n")
+	}
+	list := walkGoroot(goroot)
+	expected := []string{"fmt", "net/http", "vendor/golang.org/x/net/idna"}
+	if len(list) != len(expected) {
+		t.Fatalf("got %q, want %q", list, expected)
+	}
+	for i := range list {
+		if list[i] != expected[i] {
+			t.Fatalf("got %q, want %q", list, expected)
+		}
+	}
+
+	// The cached list of the Go version is used before the toolchain.
+	ctx := &Context{
+		Goroot:     goroot,
+		RootGopath: filepath.Join(dir, "gopath", "src"),
+		goVersion:  "go-std-test",
+	}
+	cacheFile := filepath.Join(ctx.CacheRoot(), ".dist", "go-std-test.std")
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0777); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, cacheFile, "fmt\nnet/http\nvendor/golang.org/x/net/idna\n")
+
+	for _, item := range []struct{ dir, imp, std string }{
+		{"", "fmt", "fmt"},
+		{"", "unsafe", "unsafe"},
+		{"", "github.com/a/b", ""},
+		{"", "golang.org/x/net/idna", ""},
+		{filepath.Join(goroot, "net", "http"), "golang.org/x/net/idna", "vendor/golang.org/x/net/idna"},
+		{filepath.Join(goroot, "net", "http"), "net/http", "net/http"},
+	} {
+		if got := ctx.stdImport(item.dir, item.imp); got != item.std {
+			t.Errorf("stdImport(%q, %q) = %q, want %q", item.dir, item.imp, got, item.std)
+		}
+	}
+	if yes, _ := ctx.isStdLib("os"); yes {
+		t.Error("os is not in the cached index")
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	for _, item := range []struct{ out, version string }{
+		{"go version go1.8 linux/amd64\n", "go1.8"},
+		{"go version devel +a1b2c3 Mon Jan 1 00:00:00 2018 +0000 linux/amd64\n", "devel +a1b2c3 Mon Jan 1 00:00:00 2018 +0000"},
+		{"go version\n", ""},
+	} {
+		if got := parseGoVersion(item.out); got != item.version {
+			t.Errorf("parse %q: got %q, want %q", item.out, got, item.version)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "govendor-version-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Toolchains before Go 1.16 don't list GOVERSION.
+	writeFile(t, filepath.Join(dir, "VERSION"), "go1.11\n")
+	if v := goVersion(Env{"GOROOT": dir}); v != "go1.11" {
+		t.Errorf("got version %q from the VERSION file", v)
+	}
+	if v := goVersion(Env{"GOROOT": dir, "GOVERSION": "go1.16"}); v != "go1.16" {
+		t.Errorf("got version %q with GOVERSION", v)
+	}
+	if v := goVersion(Env{}); len(v) == 0 {
+		t.Error("no version from go version")
+	}
+}
@@ -5,15 +5,10 @@
 package context
 
 import (
-	"bytes"
-	"crypto/sha1"
-	"encoding/base64"
 	"fmt"
-	"hash"
 	"io"
-	"net/url"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -24,49 +19,95 @@ import (
 	"golang.org/x/tools/go/vcs"
 )
 
-func skipperTree(name string, dir bool) bool {
+func skipperTree(rel string, dir bool) bool {
 	return false
 }
-func skipperPackage(name string, dir bool) bool {
+func skipperPackage(rel string, dir bool) bool {
 	return dir
 }
 
+// VendorMismatch is a vendor folder package that is missing or doesn't
+// match the checksums in the vendor file.
+type VendorMismatch struct {
+	Package *vendorfile.Package
+
+	// HasManifest is true if the manifest has the package file hashes.
+	// Otherwise the files that changed are not known.
+	HasManifest bool
+
+	// Files that differ from the manifest, relative to the package folder.
+	Added, Removed, Modified []string
+}
+
+// VerifyVendor returns the vendor file packages that are missing or
+// modified in the vendor folder.
 func (ctx *Context) VerifyVendor() (outOfDate []*vendorfile.Package, err error) {
-	vf := ctx.VendorFile
-	root := filepath.Join(ctx.RootDir, ctx.VendorFolder)
-	add := func(vp *vendorfile.Package) {
-		outOfDate = append(outOfDate, vp)
+	list, err := ctx.VerifyVendorDetail()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range list {
+		outOfDate = append(outOfDate, m.Package)
 	}
-	for _, vp := range vf.Package {
+	return outOfDate, nil
+}
+
+// VerifyVendorDetail is like VerifyVendor, but if the vendor file has a
+// manifest it also lists the files that were added, removed or modified.
+func (ctx *Context) VerifyVendorDetail() (outOfDate []*VendorMismatch, err error) {
+	for _, vp := range ctx.VendorFile.Package {
 		if vp.Remove {
 			continue
 		}
 		if len(vp.Path) == 0 {
 			continue
 		}
-		if len(vp.ChecksumSHA1) == 0 {
-			add(vp)
+		var h *packageHash
+		h, err = ctx.hashVendorPackage(vp)
+		if err != nil {
+			return
+		}
+		if ctx.checksumMatch(vp, h) {
 			continue
 		}
-		fp := filepath.Join(root, pathos.SlashToFilepath(vp.Path))
-		h := sha1.New()
-		sk := skipperPackage
-		if vp.Tree {
-			sk = skipperTree
+		m := &VendorMismatch{Package: vp}
+		if ctx.manifest != nil {
+			if want := ctx.manifest.Find(vp.Path); want != nil {
+				m.HasManifest = true
+				m.Added, m.Removed, m.Modified = diffFiles(want.Files, h.manifest(vp.Path).Files)
+			}
 		}
-		err = getHash(root, fp, h, sk)
-		if err != nil {
-			return
+		outOfDate = append(outOfDate, m)
+	}
+	return
+}
+
+// diffFiles compares the file hashes recorded in want with the current
+// file hashes in have.
+func diffFiles(want, have map[string]string) (added, removed, modified []string) {
+	for name, sum := range have {
+		wantSum, found := want[name]
+		switch {
+		case !found:
+			added = append(added, name)
+		case wantSum != sum:
+			modified = append(modified, name)
 		}
-		checksum := base64.StdEncoding.EncodeToString(h.Sum(nil))
-		if vp.ChecksumSHA1 != checksum {
-			add(vp)
+	}
+	for name := range want {
+		if _, found := have[name]; !found {
+			removed = append(removed, name)
 		}
 	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
 	return
 }
 
-func getHash(root, fp string, h hash.Hash, skipper func(name string, isDir bool) bool) error {
+// getHash writes the files in fp to h. The skipper is passed the slash
+// separated path of each file and folder relative to root.
+func getHash(root, fp string, h io.Writer, skipper func(rel string, isDir bool) bool) error {
 	rel := pathos.FileTrimPrefix(fp, root)
 	rel = pathos.SlashToImportPath(rel)
 	rel = strings.Trim(rel, "/")
@@ -87,7 +128,7 @@ func getHash(root, fp string, h hash.Hash, skipper func(name string, isDir bool)
 	}
 	sort.Sort(fileInfoSort(filelist))
 	for _, fi := range filelist {
-		if skipper(fi.Name(), fi.IsDir()) {
+		if skipper(path.Join(rel, fi.Name()), fi.IsDir()) {
 			continue
 		}
 		p := filepath.Join(fp, fi.Name())
@@ -103,8 +144,15 @@ func getHash(root, fp string, h hash.Hash, skipper func(name string, isDir bool)
 			return err
 		}
 		h.Write([]byte(fi.Name()))
+		fh, _ := h.(fileHasher)
+		if fh != nil {
+			fh.beginFile(path.Join(rel, fi.Name()))
+		}
 		_, err = io.Copy(h, f)
 		f.Close()
+		if fh != nil {
+			fh.endFile()
+		}
 		if err != nil {
 			return err
 		}
@@ -112,154 +160,6 @@ func getHash(root, fp string, h hash.Hash, skipper func(name string, isDir bool)
 	return nil
 }
 
-type remoteFailure struct {
-	Path string
-	Msg  string
-	Err  error
-}
-
-func (fail remoteFailure) Error() string {
-	return fmt.Sprintf("Failed for %q (%s): %v", fail.Path, fail.Msg, fail.Err)
-}
-
-type remoteFailureList []remoteFailure
-
-func (list remoteFailureList) Error() string {
-	if len(list) == 0 {
-		return "(no remote failure)"
-	}
-	buf := &bytes.Buffer{}
-	buf.WriteString("Remotes failed for:\n")
-	for _, item := range list {
-		buf.WriteString("\t")
-		buf.WriteString(item.Error())
-		buf.WriteString("\n")
-	}
-	return buf.String()
-}
-
-type VCSCmd struct {
-	*vcs.Cmd
-}
-
-func (vcsCmd *VCSCmd) RevisionSync(dir, revision string) error {
-	return vcsCmd.run(dir, vcsCmd.TagSyncCmd, "tag", revision)
-}
-
-func (v *VCSCmd) run(dir string, cmd string, keyval ...string) error {
-	_, err := v.run1(dir, cmd, keyval, true)
-	return err
-}
-
-// run1 is the generalized implementation of run and runOutput.
-func (vcsCmd *VCSCmd) run1(dir string, cmdline string, keyval []string, verbose bool) ([]byte, error) {
-	v := vcsCmd.Cmd
-	m := make(map[string]string)
-	for i := 0; i < len(keyval); i += 2 {
-		m[keyval[i]] = keyval[i+1]
-	}
-	args := strings.Fields(cmdline)
-	for i, arg := range args {
-		args[i] = expand(m, arg)
-	}
-
-	_, err := exec.LookPath(v.Cmd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr,
-			"go: missing %s command. See http://golang.org/s/gogetcmd\n",
-			v.Name)
-		return nil, err
-	}
-
-	cmd := exec.Command(v.Cmd, args...)
-	cmd.Dir = dir
-	cmd.Env = envForDir(cmd.Dir)
-	if vcs.ShowCmd {
-		fmt.Printf("cd %s\n", dir)
-		fmt.Printf("%s %s\n", v.Cmd, strings.Join(args, " "))
-	}
-	var buf bytes.Buffer
-	cmd.Stdout = &buf
-	cmd.Stderr = &buf
-	err = cmd.Run()
-	out := buf.Bytes()
-	if err != nil {
-		if verbose {
-			fmt.Fprintf(os.Stderr, "# cd %s; %s %s\n", dir, v.Cmd, strings.Join(args, " "))
-			os.Stderr.Write(out)
-		}
-		return nil, err
-	}
-	return out, nil
-}
-
-// expand rewrites s to replace {k} with match[k] for each key k in match.
-func expand(match map[string]string, s string) string {
-	for k, v := range match {
-		s = strings.Replace(s, "{"+k+"}", v, -1)
-	}
-	return s
-}
-
-// envForDir returns a copy of the environment
-// suitable for running in the given directory.
-// The environment is the current process's environment
-// but with an updated $PWD, so that an os.Getwd in the
-// child will be faster.
-func envForDir(dir string) []string {
-	env := os.Environ()
-	// Internally we only use rooted paths, so dir is rooted.
-	// Even if dir is not rooted, no harm done.
-	return mergeEnvLists([]string{"PWD=" + dir}, env)
-}
-
-// mergeEnvLists merges the two environment lists such that
-// variables with the same name in "in" replace those in "out".
-func mergeEnvLists(in, out []string) []string {
-NextVar:
-	for _, inkv := range in {
-		k := strings.SplitAfterN(inkv, "=", 2)[0]
-		for i, outkv := range out {
-			if strings.HasPrefix(outkv, k) {
-				out[i] = inkv
-				continue NextVar
-			}
-		}
-		out = append(out, inkv)
-	}
-	return out
-}
-
-func updateVcsCmd(cmd *vcs.Cmd) *VCSCmd {
-	switch cmd.Name {
-	case "Git":
-		cmd.TagSyncCmd = "reset --hard {tag}"
-		cmd.TagSyncDefault = "reset --hard origin/master"
-		cmd.DownloadCmd = "fetch"
-	case "Mercurial":
-	case "Bazaar":
-	case "Subversion":
-	}
-	return &VCSCmd{Cmd: cmd}
-}
-
-var isSecureScheme = map[string]bool{
-	"https":   true,
-	"git+ssh": true,
-	"bzr+ssh": true,
-	"svn+ssh": true,
-	"ssh":     true,
-}
-
-func vcsIsSecure(repo string) bool {
-	u, err := url.Parse(repo)
-	if err != nil {
-		// If repo is not a URL, it's not secure.
-		return false
-	}
-	return isSecureScheme[u.Scheme]
-}
-
 // Sync checks for outdated packages in the vendor folder and fetches the
 // correct revision from the remote.
 func (ctx *Context) Sync(dryrun bool) (err error) {
@@ -268,24 +168,36 @@ func (ctx *Context) Sync(dryrun bool) (err error) {
 	if err != nil {
 		return fmt.Errorf("Failed to verify checksums: %v", err)
 	}
-	// GOPATH includes the src dir, move up a level.
-	cacheRoot := filepath.Join(ctx.RootGopath, "..", ".cache", "govendor")
+	cacheRoot := ctx.CacheRoot()
 	err = os.MkdirAll(cacheRoot, 0700)
 	if err != nil {
 		return err
 	}
 
 	// collect errors and proceed where you can.
-	rem := remoteFailureList{}
+	rem := ErrRemoteFailures{}
 
-	h := sha1.New()
+	h := newPackageHash()
 	updatedVendorFile := false
 
+	// Stage the changes, so if a patch no longer applies the vendor folder
+	// and vendor file are left unchanged.
+	var tx *vendorTx
+	if !dryrun && len(outOfDate) > 0 {
+		tx, err = ctx.beginTx()
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, vp := range outOfDate {
 		// Bundle packages together that have the same revision and share at least one root segment.
 		if len(vp.Revision) == 0 {
 			continue
 		}
+		if err = ctx.interrupted(); err != nil {
+			break
+		}
 		from := vp.Path
 		if len(vp.Origin) > 0 {
 			from = vp.Origin
@@ -300,86 +212,68 @@ func (ctx *Context) Sync(dryrun bool) (err error) {
 		}
 		pkgDir := filepath.Join(cacheRoot, from)
 
-		// See if repo exists.
-		sysVcsCmd, repoRoot, err := vcs.FromDir(pkgDir, cacheRoot)
-		var vcsCmd *VCSCmd
-		repoRootDir := filepath.Join(cacheRoot, repoRoot)
+		vcsCmd, repoRootDir, err := ctx.openCacheRepo(cacheRoot, from, vp.Revision)
 		if err != nil {
-			rr, err := vcs.RepoRootForImportPath(from, false)
-			if err != nil {
-				rem = append(rem, remoteFailure{Msg: "failed to ping remote repo", Path: vp.Path, Err: err})
-				continue
-			}
-			if !ctx.Insecure && !vcsIsSecure(rr.Repo) {
-				rem = append(rem, remoteFailure{Msg: "repo remote not secure", Path: vp.Path, Err: nil})
-				continue
-			}
-
-			vcsCmd = updateVcsCmd(rr.VCS)
-
-			repoRoot = rr.Root
-			repoRootDir = filepath.Join(cacheRoot, repoRoot)
-			err = os.MkdirAll(repoRootDir, 0700)
-			if err != nil {
-				rem = append(rem, remoteFailure{Msg: "failed to make repo root dir", Path: vp.Path, Err: err})
-				continue
-			}
-
-			err = vcsCmd.CreateAtRev(repoRootDir, rr.Repo, vp.Revision)
-			if err != nil {
-				rem = append(rem, remoteFailure{Msg: "failed to clone repo", Path: vp.Path, Err: err})
-				continue
-			}
-		} else {
-			// Use cache.
-			vcsCmd = updateVcsCmd(sysVcsCmd)
-
-			err = vcsCmd.RevisionSync(repoRootDir, vp.Revision)
-			// If revision was not found in the cache, download and try again.
-			if err != nil {
-				err = vcsCmd.Download(repoRootDir)
-				if err != nil {
-					rem = append(rem, remoteFailure{Msg: "failed to download repo", Path: vp.Path, Err: err})
-					continue
-				}
-				err = vcsCmd.RevisionSync(repoRootDir, vp.Revision)
-				if err != nil {
-					rem = append(rem, remoteFailure{Msg: "failed to sync repo to " + vp.Revision, Path: vp.Path, Err: err})
-					continue
-				}
-			}
+			fail := err.(RemoteFailure)
+			fail.Path = vp.Path
+			rem = append(rem, fail)
+			continue
+		}
+		err = ctx.cacheRevisionSync(vcsCmd, repoRootDir, vp.Revision)
+		if err != nil {
+			fail := err.(RemoteFailure)
+			fail.Path = vp.Path
+			rem = append(rem, fail)
+			continue
 		}
+		touchCacheRepo(repoRootDir)
 		dest := filepath.Join(ctx.RootDir, ctx.VendorFolder, pathos.SlashToFilepath(vp.Path))
 		// Path handling with single sub-packages and differing origins need to be properly handled.
 		src := pkgDir
 
 		// Scan go files for files that should be ignored based on tags and filenames.
-		ignoreFiles, _, err := ctx.getIgnoreFiles(src)
+		ignoreFiles, _, err := ctx.packageIgnoreFiles(vp.Path, src)
 		if err != nil {
-			rem = append(rem, remoteFailure{Msg: "failed to get ignore files", Path: vp.Path, Err: err})
+			rem = append(rem, RemoteFailure{Msg: "failed to get ignore files", Path: vp.Path, Err: err})
 			continue
 		}
 
 		root, _ := pathos.TrimCommonSuffix(src, vp.Path)
 
 		// Need to ensure we copy files from "b.Root/<import-path>" for the following command.
-		err = ctx.CopyPackage(dest, src, root, vp.Path, ignoreFiles, vp.Tree, h, nil)
+		span := ctx.startEvent(Event{Kind: EventCopy, Package: vp.Path, Path: dest, Revision: vp.Revision})
+		err = ctx.CopyPackage(ctx.stagePath(dest), src, root, vp.Path, ignoreFiles, vp.Tree, h, nil)
+		span.Files = len(h.files)
+		span.finish(h.n, err)
 		if err != nil {
 			fmt.Fprintf(ctx, "failed to copy package from %q to %q: %+v", src, dest, err)
 		}
-		checksum := h.Sum(nil)
+		patched, err := ctx.patchPackage(vp)
+		if err != nil {
+			tx.rollback()
+			return err
+		}
+		if patched != nil {
+			ctx.setChecksum(vp, patched)
+		} else {
+			ctx.setChecksum(vp, h)
+		}
 		h.Reset()
-		vp.ChecksumSHA1 = base64.StdEncoding.EncodeToString(checksum)
 		updatedVendorFile = true
 	}
 
 	// Only write a vendor file if something changes.
-	if updatedVendorFile {
-		err = ctx.WriteVendorFile()
-		if err != nil {
-			return err
+	// Keep the packages already synced if interrupted.
+	if tx != nil {
+		if !updatedVendorFile {
+			tx.rollback()
+		} else if werr := tx.commit(); werr != nil {
+			return werr
 		}
 	}
+	if err = ctx.interrupted(); err != nil {
+		return err
+	}
 
 	// Return network errors here.
 	if len(rem) > 0 {
@@ -388,3 +282,73 @@ func (ctx *Context) Sync(dryrun bool) (err error) {
 
 	return nil
 }
+
+// openCacheRepo returns the cached repo that contains the package from.
+// If the repo isn't in the cache it is cloned, only the revision if one
+// is given. A returned error is a RemoteFailure without the path.
+func (ctx *Context) openCacheRepo(cacheRoot, from, revision string) (*VCSCmd, string, error) {
+	pkgDir := filepath.Join(cacheRoot, pathos.SlashToFilepath(from))
+	sysVcsCmd, repoRoot, err := vcs.FromDir(pkgDir, cacheRoot)
+	if err == nil && removeAbandoned(filepath.Join(cacheRoot, repoRoot)) {
+		err = errAbandoned
+	}
+	if err == nil {
+		vcsCmd := ctx.newVcsCmd(sysVcsCmd)
+		repoRootDir := filepath.Join(cacheRoot, repoRoot)
+		err = ctx.Auth.setup(vcsCmd, "", repoRootDir)
+		if err != nil {
+			return nil, "", RemoteFailure{Msg: "failed to get credentials", Err: err}
+		}
+		return vcsCmd, repoRootDir, nil
+	}
+	rr, err := ctx.repoRoot(from)
+	if err != nil {
+		return nil, "", RemoteFailure{Msg: "failed to ping remote repo", Err: err}
+	}
+	if !ctx.Insecure && !vcsIsSecure(rr.Repo) {
+		return nil, "", RemoteFailure{Msg: "repo remote not secure", Err: nil}
+	}
+	vcsCmd := ctx.newVcsCmd(rr.VCS)
+	repoRootDir := filepath.Join(cacheRoot, rr.Root)
+
+	err = ctx.Auth.setup(vcsCmd, rr.Repo, repoRootDir)
+	if err != nil {
+		return nil, "", RemoteFailure{Msg: "failed to get credentials", Err: err}
+	}
+	span := ctx.startEvent(Event{Kind: EventClone, Package: from, Repo: rr.Repo, Path: repoRootDir, Revision: revision})
+	err = ctx.retry("clone "+rr.Repo, func() error {
+		if len(revision) > 0 {
+			return vcsCmd.CreateShallow(repoRootDir, rr.Repo, revision)
+		}
+		return vcsCmd.Create(repoRootDir, rr.Repo)
+	})
+	span.finish(ctx.folderSize(repoRootDir), err)
+	if err != nil {
+		return nil, "", RemoteFailure{Msg: "failed to clone repo", Err: err}
+	}
+	return vcsCmd, repoRootDir, nil
+}
+
+// cacheRevisionSync updates the cached repo to the revision. If the
+// revision is not in the cache it is downloaded. A returned error is a
+// RemoteFailure without the path.
+func (ctx *Context) cacheRevisionSync(vcsCmd *VCSCmd, repoRootDir, revision string) error {
+	err := vcsCmd.RevisionSync(repoRootDir, revision)
+	if err != nil {
+		before := ctx.folderSize(repoRootDir)
+		span := ctx.startEvent(Event{Kind: EventDownload, Path: repoRootDir, Revision: revision})
+		err = ctx.retry("download "+repoRootDir, func() error {
+			return vcsCmd.DownloadRevision(repoRootDir, revision)
+		})
+		span.finish(grown(before, ctx.folderSize(repoRootDir)), err)
+		if err != nil {
+			return RemoteFailure{Msg: "failed to download repo", Err: err}
+		}
+		err = vcsCmd.RevisionSync(repoRootDir, revision)
+		if err != nil {
+			return RemoteFailure{Msg: "failed to sync repo to " + revision, Err: err}
+		}
+	}
+	touchCacheRepo(repoRootDir)
+	return nil
+}
@@ -0,0 +1,382 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// localRepo creates a repository with two commits of "a.txt" and a tag
+// "v1.0.0" on the first commit. It returns the repo to clone from and the
+// revision of the first commit.
+type localRepo func(t *testing.T, run func(dir string, args ...string) string, base string) (repo, rev string)
+
+var localRepoList = []struct {
+	Cmd    string
+	Create localRepo
+}{
+	{
+		Cmd: "git",
+		Create: func(t *testing.T, run func(dir string, args ...string) string, base string) (string, string) {
+			dir := filepath.Join(base, "repo")
+			run(base, "init", dir)
+			run(dir, "symbolic-ref", "HEAD", "refs/heads/master")
+			run(dir, "config", "user.name", "tests")
+			run(dir, "config", "user.email", "tests@govendor.io")
+			writeFile(t, filepath.Join(dir, "a.txt"), "1")
+			run(dir, "add", "-A")
+			run(dir, "commit", "-m", "one")
+			run(dir, "tag", "v1.0.0")
+			rev := strings.TrimSpace(run(dir, "rev-parse", "HEAD"))
+			writeFile(t, filepath.Join(dir, "a.txt"), "2")
+			run(dir, "commit", "-a", "-m", "two")
+			return dir, rev
+		},
+	},
+	{
+		Cmd: "hg",
+		Create: func(t *testing.T, run func(dir string, args ...string) string, base string) (string, string) {
+			dir := filepath.Join(base, "repo")
+			run(base, "init", dir)
+			writeFile(t, filepath.Join(dir, "a.txt"), "1")
+			run(dir, "add", "a.txt")
+			run(dir, "commit", "-u", "tests", "-m", "one")
+			rev := strings.TrimSpace(run(dir, "log", "-r", ".", "--template", "{node}"))
+			run(dir, "tag", "-u", "tests", "v1.0.0")
+			writeFile(t, filepath.Join(dir, "a.txt"), "2")
+			run(dir, "commit", "-u", "tests", "-m", "two")
+			return dir, rev
+		},
+	},
+	{
+		Cmd: "bzr",
+		Create: func(t *testing.T, run func(dir string, args ...string) string, base string) (string, string) {
+			dir := filepath.Join(base, "repo")
+			run(base, "init", dir)
+			writeFile(t, filepath.Join(dir, "a.txt"), "1")
+			run(dir, "add", "a.txt")
+			run(dir, "commit", "-m", "one")
+			run(dir, "tag", "v1.0.0")
+			rev := strings.TrimSpace(run(dir, "revno"))
+			writeFile(t, filepath.Join(dir, "a.txt"), "2")
+			run(dir, "commit", "-m", "two")
+			return dir, rev
+		},
+	},
+	{
+		Cmd: "svn",
+		Create: func(t *testing.T, run func(dir string, args ...string) string, base string) (string, string) {
+			svnadmin, err := exec.LookPath("svnadmin")
+			if err != nil {
+				t.Skip("svnadmin not found")
+			}
+			server := filepath.Join(base, "server")
+			out, err := exec.Command(svnadmin, "create", server).CombinedOutput()
+			if err != nil {
+				t.Fatalf("svnadmin create: %v\n%s", err, out)
+			}
+			root := "file://" + filepath.ToSlash(server)
+			run(base, "mkdir", "-m", "layout", root+"/trunk", root+"/tags")
+			dir := filepath.Join(base, "wc")
+			run(base, "checkout", root+"/trunk", dir)
+			writeFile(t, filepath.Join(dir, "a.txt"), "1")
+			run(dir, "add", "a.txt")
+			run(dir, "commit", "-m", "one")
+			run(dir, "update")
+			rev := strings.TrimSpace(run(dir, "info", "--show-item", "last-changed-revision"))
+			run(dir, "copy", "-m", "tag", "^/trunk", "^/tags/v1.0.0")
+			writeFile(t, filepath.Join(dir, "a.txt"), "2")
+			run(dir, "commit", "-m", "two")
+			return root + "/trunk", rev
+		},
+	},
+}
+
+func writeFile(t *testing.T, name, content string) {
+	err := ioutil.WriteFile(name, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVCSCmdSync(t *testing.T) {
+	for _, item := range localRepoList {
+		item := item
+		t.Run(item.Cmd, func(t *testing.T) {
+			execPath, err := exec.LookPath(item.Cmd)
+			if err != nil {
+				t.Skip("unsupported vcs")
+			}
+			base, err := ioutil.TempDir("", "govendor_vcs_")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(base)
+
+			run := func(dir string, args ...string) string {
+				cmd := exec.Command(execPath, args...)
+				cmd.Dir = dir
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("Failed to run %q %q: %v\n%s", execPath, args, err, out)
+				}
+				return string(out)
+			}
+			repo, rev := item.Create(t, run, base)
+
+			check := func(name, dir, want string) {
+				got, err := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+				if err != nil {
+					t.Fatalf("(%s) %v", name, err)
+				}
+				if string(got) != want {
+					t.Fatalf("(%s) got %q, want %q", name, got, want)
+				}
+			}
+
+			vcsCmd := updateVcsCmd(vcs.ByCmd(item.Cmd))
+
+			// The cache creates the repo dir before cloning, ensure that works.
+			cache := filepath.Join(base, "cache", "repo")
+			err = os.MkdirAll(cache, 0700)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = vcsCmd.CreateAtRev(cache, repo, rev)
+			if err != nil {
+				t.Fatal("CreateAtRev", err)
+			}
+			check("create at rev", cache, "1")
+
+			err = vcsCmd.Download(cache)
+			if err != nil {
+				t.Fatal("Download", err)
+			}
+			err = vcsCmd.TagSync(cache, "")
+			if err != nil {
+				t.Fatal("TagSync default", err)
+			}
+			check("default", cache, "2")
+
+			tags, err := vcsCmd.Tags(cache)
+			if err != nil {
+				t.Fatal("Tags", err)
+			}
+			found := false
+			for _, tag := range tags {
+				if tag == "v1.0.0" {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("missing tag v1.0.0 in %q", tags)
+			}
+			err = vcsCmd.TagSync(cache, "v1.0.0")
+			if err != nil {
+				t.Fatal("TagSync", err)
+			}
+			check("tag", cache, "1")
+
+			err = vcsCmd.TagSync(cache, "")
+			if err != nil {
+				t.Fatal("TagSync default", err)
+			}
+			err = vcsCmd.RevisionSync(cache, rev)
+			if err != nil {
+				t.Fatal("RevisionSync", err)
+			}
+			check("revision", cache, "1")
+
+			// Create into a missing parent directory.
+			fresh := filepath.Join(base, "cache2", "a", "repo")
+			err = vcsCmd.CreateAtRev(fresh, repo, rev)
+			if err != nil {
+				t.Fatal("CreateAtRev fresh", err)
+			}
+			check("create at rev fresh", fresh, "1")
+		})
+	}
+}
+
+func TestVCSCmdLayout(t *testing.T) {
+	if _, err := exec.LookPath("printf"); err != nil {
+		t.Skip("printf not found")
+	}
+	// printf prints the folder list in place of "svn list".
+	vcsCmd := &VCSCmd{
+		Cmd:       &vcs.Cmd{Name: "printf", Cmd: "printf", TagCmd: []vcs.TagCmd{{Cmd: `v1/\n`, Pattern: `^(\S+)/$`}}},
+		LayoutCmd: `branches/\ntrunk/\n`,
+	}
+	tags, err := vcsCmd.Tags(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("got tags %q without a tags folder", tags)
+	}
+	vcsCmd.LayoutCmd = `tags/\ntrunk/\n`
+	tags, err = vcsCmd.Tags(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0] != "v1" {
+		t.Fatalf("got tags %q", tags)
+	}
+}
+
+// TestVCSCmdSyncFlat syncs a Subversion repo without the standard
+// "trunk", "tags" layout.
+func TestVCSCmdSyncFlat(t *testing.T) {
+	execPath, err := exec.LookPath("svn")
+	if err != nil {
+		t.Skip("unsupported vcs")
+	}
+	svnadmin, err := exec.LookPath("svnadmin")
+	if err != nil {
+		t.Skip("svnadmin not found")
+	}
+	base, err := ioutil.TempDir("", "govendor_vcs_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command(execPath, args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Failed to run %q %q: %v\n%s", execPath, args, err, out)
+		}
+		return string(out)
+	}
+	server := filepath.Join(base, "server")
+	out, err := exec.Command(svnadmin, "create", server).CombinedOutput()
+	if err != nil {
+		t.Fatalf("svnadmin create: %v\n%s", err, out)
+	}
+	repo := "file://" + filepath.ToSlash(server)
+	wc := filepath.Join(base, "wc")
+	run(base, "checkout", repo, wc)
+	writeFile(t, filepath.Join(wc, "a.txt"), "1")
+	run(wc, "add", "a.txt")
+	run(wc, "commit", "-m", "one")
+	run(wc, "update")
+	rev := strings.TrimSpace(run(wc, "info", "--show-item", "last-changed-revision"))
+	writeFile(t, filepath.Join(wc, "a.txt"), "2")
+	run(wc, "commit", "-m", "two")
+
+	check := func(name, dir, want string) {
+		got, err := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+		if err != nil {
+			t.Fatalf("(%s) %v", name, err)
+		}
+		if string(got) != want {
+			t.Fatalf("(%s) got %q, want %q", name, got, want)
+		}
+	}
+
+	vcsCmd := updateVcsCmd(vcs.ByCmd("svn"))
+	cache := filepath.Join(base, "cache", "repo")
+	err = vcsCmd.CreateAtRev(cache, repo, rev)
+	if err != nil {
+		t.Fatal("CreateAtRev", err)
+	}
+	check("create at rev", cache, "1")
+
+	tags, err := vcsCmd.Tags(cache)
+	if err != nil {
+		t.Fatal("Tags", err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("got tags %q", tags)
+	}
+	err = vcsCmd.TagSync(cache, "")
+	if err != nil {
+		t.Fatal("TagSync default", err)
+	}
+	check("default", cache, "2")
+}
+
+func TestVCSCmdShallow(t *testing.T) {
+	execPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("unsupported vcs")
+	}
+	base, err := ioutil.TempDir("", "govendor_vcs_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command(execPath, args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Failed to run %q %q: %v\n%s", execPath, args, err, out)
+		}
+		return string(out)
+	}
+	dir, rev := localRepoList[0].Create(t, run, base)
+	repo := "file://" + filepath.ToSlash(dir)
+	head := strings.TrimSpace(run(dir, "rev-parse", "HEAD"))
+
+	vcsCmd := updateVcsCmd(vcs.ByCmd("git"))
+	cache := filepath.Join(base, "cache", "repo")
+	err = vcsCmd.CreateShallow(cache, repo, rev)
+	if err != nil {
+		t.Fatal("CreateShallow", err)
+	}
+	if !vcsCmd.IsShallow(cache) {
+		t.Fatal("expected a shallow repo")
+	}
+	err = vcsCmd.RevisionSync(cache, rev)
+	if err != nil {
+		t.Fatal("RevisionSync", err)
+	}
+	if got := strings.TrimSpace(run(cache, "rev-list", "--count", "HEAD")); got != "1" {
+		t.Fatalf("got %s commits, want 1", got)
+	}
+
+	// Fetch another single revision.
+	err = vcsCmd.DownloadRevision(cache, head)
+	if err != nil {
+		t.Fatal("DownloadRevision", err)
+	}
+	err = vcsCmd.RevisionSync(cache, head)
+	if err != nil {
+		t.Fatal("RevisionSync head", err)
+	}
+
+	// Tags need the full history.
+	err = vcsCmd.Deepen(cache)
+	if err != nil {
+		t.Fatal("Deepen", err)
+	}
+	if vcsCmd.IsShallow(cache) {
+		t.Fatal("expected a full repo")
+	}
+	tags, err := vcsCmd.Tags(cache)
+	if err != nil {
+		t.Fatal("Tags", err)
+	}
+	found := false
+	for _, tag := range tags {
+		if tag == "v1.0.0" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("missing tag v1.0.0 in %q", tags)
+	}
+}
@@ -4,5 +4,124 @@
 
 package context
 
-const goosList = "android darwin dragonfly freebsd linux nacl netbsd openbsd plan9 solaris windows "
-const goarchList = "386 amd64 amd64p32 arm armbe arm64 arm64be ppc64 ppc64le mips mipsle mips64 mips64le mips64p32 mips64p32le ppc s390 s390x sparc sparc64 "
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// goosList and goarchList are used if the toolchain can't list its
+// platforms. They also include the names go/build reserves for future
+// ports, files with these suffixes are not built by any toolchain.
+const goosList = "aix android darwin dragonfly freebsd hurd illumos ios js linux nacl netbsd openbsd plan9 solaris wasip1 windows zos "
+const goarchList = "386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64 mips64le mips64p32 mips64p32le ppc ppc64 ppc64le riscv riscv64 s390 s390x sparc sparc64 wasm "
+
+var knownOS = make(map[string]bool)
+var knownArch = make(map[string]bool)
+
+func init() {
+	for _, v := range strings.Fields(goosList) {
+		knownOS[v] = true
+	}
+	for _, v := range strings.Fields(goarchList) {
+		knownArch[v] = true
+	}
+}
+
+var distListOnce sync.Once
+
+// loadDistList adds the platforms of the active toolchain to knownOS and
+// knownArch. It is only run once.
+func (ctx *Context) loadDistList(goVersion string) {
+	distListOnce.Do(func() {
+		list, err := distList(filepath.Join(ctx.CacheRoot(), ".dist"), goVersion)
+		if err != nil {
+			dprintf("go tool dist list: %v\n", err)
+			return
+		}
+		addDistList(knownOS, knownArch, list)
+	})
+}
+
+// distList returns the "goos/goarch" lines of "go tool dist list". The
+// output is cached in cacheDir for each Go version.
+func distList(cacheDir, goVersion string) ([]string, error) {
+	cacheFile := ""
+	if len(goVersion) > 0 {
+		cacheFile = filepath.Join(cacheDir, cacheFileName(goVersion))
+		if b, err := ioutil.ReadFile(cacheFile); err == nil {
+			return strings.Fields(string(b)), nil
+		}
+	}
+	out, err := exec.Command("go", "tool", "dist", "list").Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(cacheFile) > 0 {
+		// The list is run again next time if it can't be cached.
+		if os.MkdirAll(cacheDir, 0700) == nil {
+			ioutil.WriteFile(cacheFile, out, 0600)
+		}
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// goVersion returns the version of the Go toolchain, used to name cached
+// toolchain lists. "go env" only lists GOVERSION from Go 1.16, so older
+// toolchains are asked with the GOROOT VERSION file or "go version".
+func goVersion(env Env) string {
+	if v := env["GOVERSION"]; len(v) > 0 {
+		return v
+	}
+	if len(env["GOROOT"]) > 0 {
+		if b, err := ioutil.ReadFile(filepath.Join(env["GOROOT"], "VERSION")); err == nil {
+			// Newer release builds list more on the following lines.
+			if v := strings.TrimSpace(strings.SplitN(string(b), "\n", 2)[0]); len(v) > 0 {
+				return v
+			}
+		}
+	}
+	out, err := exec.Command("go", "version").Output()
+	if err != nil {
+		dprintf("go version: %v\n", err)
+		return ""
+	}
+	return parseGoVersion(string(out))
+}
+
+// parseGoVersion returns the version in the output of "go version", such
+// as "go1.11" in "go version go1.11 linux/amd64".
+func parseGoVersion(out string) string {
+	f := strings.Fields(out)
+	if len(f) < 4 || f[0] != "go" || f[1] != "version" {
+		return ""
+	}
+	// Drop the platform.
+	return strings.Join(f[2:len(f)-1], " ")
+}
+
+// cacheFileName returns a file name for the Go version, which may contain
+// spaces or other characters in development builds.
+func cacheFileName(goVersion string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, goVersion)
+}
+
+func addDistList(goos, goarch map[string]bool, list []string) {
+	for _, item := range list {
+		i := strings.IndexByte(item, '/')
+		if i <= 0 || i == len(item)-1 {
+			continue
+		}
+		goos[item[:i]] = true
+		goarch[item[i+1:]] = true
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDistList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "govendor-dist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const version = "devel go1.99-abc Mon Jan 1"
+	name := cacheFileName(version)
+	if name != "devel_go1.99-abc_Mon_Jan_1" {
+		t.Fatalf("unexpected cache file name %q", name)
+	}
+	writeFile(t, filepath.Join(dir, name), "linux/riscv64\nnewos/newarch\n")
+	list, err := distList(dir, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goos, goarch := map[string]bool{}, map[string]bool{}
+	addDistList(goos, goarch, append(list, "bad", "/", "x/"))
+	if len(goos) != 2 || !goos["linux"] || !goos["newos"] {
+		t.Errorf("unexpected goos %v", goos)
+	}
+	if len(goarch) != 2 || !goarch["riscv64"] || !goarch["newarch"] {
+		t.Errorf("unexpected goarch %v", goarch)
+	}
+
+	// Without a cached list the toolchain is run and the list is cached.
+	list, err = distList(dir, "go-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, item := range list {
+		found = found || item == "linux/amd64"
+	}
+	if !found {
+		t.Fatalf("linux/amd64 not listed in %q", list)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go-test")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The list is cached without GOVERSION, which older toolchains lack.
+	toolchain := goVersion(Env{})
+	if len(toolchain) == 0 {
+		t.Fatal("no Go version")
+	}
+	_, err = distList(dir, toolchain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheFileName(toolchain))); err != nil {
+		t.Fatal(err)
+	}
+
+	// The built-in table covers newer ports.
+	for _, name := range []string{"aix", "illumos", "ios", "js", "wasip1"} {
+		if !knownOS[name] {
+			t.Errorf("GOOS %q not known", name)
+		}
+	}
+	for _, name := range []string{"riscv64", "loong64", "wasm"} {
+		if !knownArch[name] {
+			t.Errorf("GOARCH %q not known", name)
+		}
+	}
+}
@@ -6,10 +6,12 @@ package context
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 )
 
 // Build tags come in the format "tagA tagB,tagC" -> "taga OR (tagB AND tagC)"
+// or the "//go:build" format "tagA || (tagB && tagC)".
 // File tags compose with this as "ftag1 AND ftag2 AND (<build-tags>)".
 // However in govendor all questions are reversed. Rather than asking
 // "What should be built?" we ask "What should be ignored?".
@@ -238,3 +240,139 @@ func (ts *TagSet) AddBuildTags(tags string) {
 
 	}
 }
+
+// AddBuildExpr adds the "//go:build" expression, such as
+// "linux && (amd64 || arm64) && !appengine". Negations are moved to the
+// tags so the expression is a logical of tags.
+func (ts *TagSet) AddBuildExpr(expr string) error {
+	if ts == nil {
+		return nil
+	}
+	p := &exprParser{s: expr}
+	l, err := p.parse()
+	if err != nil {
+		return err
+	}
+	if l.ignored([]logicalTag{{tag: "ignore"}}) {
+		ts.ignore = true
+	}
+	ts.root.and = true
+	ts.root.sub = append(ts.root.sub, l)
+	return nil
+}
+
+// exprParser parses a "//go:build" expression into a logical.
+type exprParser struct {
+	s   string
+	pos int
+	tok string // Current token, empty at the end.
+}
+
+func (p *exprParser) parse() (logical, error) {
+	p.next()
+	l, err := p.or(false)
+	if err != nil {
+		return logical{}, err
+	}
+	if len(p.tok) != 0 {
+		return logical{}, fmt.Errorf("unexpected %q in build expression %q", p.tok, p.s)
+	}
+	return l, nil
+}
+
+// next reads the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	switch {
+	case p.pos >= len(p.s):
+	case strings.HasPrefix(p.s[p.pos:], "&&"), strings.HasPrefix(p.s[p.pos:], "||"):
+		p.pos += 2
+	case p.s[p.pos] == '!', p.s[p.pos] == '(', p.s[p.pos] == ')':
+		p.pos++
+	default:
+		for p.pos < len(p.s) && isTagChar(p.s[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			// Unknown character, return it as a token to report.
+			p.pos++
+		}
+	}
+	p.tok = p.s[start:p.pos]
+}
+
+func isTagChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// or parses "x || y". If not is true the result is negated.
+func (p *exprParser) or(not bool) (logical, error) {
+	return p.binary("||", not, p.and)
+}
+
+// and parses "x && y". If not is true the result is negated.
+func (p *exprParser) and(not bool) (logical, error) {
+	return p.binary("&&", not, p.unary)
+}
+
+func (p *exprParser) binary(op string, not bool, operand func(not bool) (logical, error)) (logical, error) {
+	// Negating an AND results in an OR of the negated operands.
+	l := logical{and: (op == "&&") != not}
+	for {
+		x, err := operand(not)
+		if err != nil {
+			return logical{}, err
+		}
+		switch {
+		case len(x.sub) == 0 && len(x.tag) == 1:
+			l.tag = append(l.tag, x.tag[0])
+		case x.and == l.and:
+			l.tag = append(l.tag, x.tag...)
+			l.sub = append(l.sub, x.sub...)
+		default:
+			l.sub = append(l.sub, x)
+		}
+		if p.tok != op {
+			break
+		}
+		p.next()
+	}
+	switch {
+	case len(l.sub) == 0 && len(l.tag) == 1:
+		// A single tag is the same in an AND or OR.
+		l.and = true
+	case len(l.sub) == 1 && len(l.tag) == 0:
+		return l.sub[0], nil
+	}
+	return l, nil
+}
+
+// unary parses a tag, "!x" or "(x)". If not is true the result is negated.
+func (p *exprParser) unary(not bool) (logical, error) {
+	switch tok := p.tok; {
+	case tok == "!":
+		p.next()
+		return p.unary(!not)
+	case tok == "(":
+		p.next()
+		l, err := p.or(not)
+		if err != nil {
+			return logical{}, err
+		}
+		if p.tok != ")" {
+			return logical{}, fmt.Errorf("missing ) in build expression %q", p.s)
+		}
+		p.next()
+		return l, nil
+	case len(tok) > 0 && isTagChar(tok[0]):
+		p.next()
+		return logical{and: true, tag: []logicalTag{{not: not, tag: tok}}}, nil
+	case len(tok) == 0:
+		return logical{}, fmt.Errorf("unexpected end of build expression %q", p.s)
+	default:
+		return logical{}, fmt.Errorf("unexpected %q in build expression %q", tok, p.s)
+	}
+}
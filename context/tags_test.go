@@ -5,6 +5,9 @@
 package context
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -14,6 +17,7 @@ func TestTagComplex(t *testing.T) {
 		ignoreList string
 		file       []string
 		buildTags  string
+		goBuild    string
 		ignored    bool
 	}{
 		{
@@ -88,6 +92,63 @@ func TestTagComplex(t *testing.T) {
 			buildTags:  "go1.8",
 			ignored:    true,
 		},
+		{
+			ignoreList: "",
+			goBuild:    "ignore",
+			ignored:    true,
+		},
+		{
+			ignoreList: "",
+			goBuild:    "ignore || linux",
+			ignored:    false,
+		},
+		{
+			ignoreList: "appengine test",
+			goBuild:    "!appengine",
+			ignored:    false,
+		},
+		{
+			ignoreList: "appengine test",
+			goBuild:    "linux && appengine",
+			ignored:    true,
+		},
+		{
+			ignoreList: "appengine test",
+			goBuild:    "linux || appengine",
+			ignored:    false,
+		},
+		{
+			ignoreList: "mips appengine test",
+			goBuild:    "(mips || appengine) && linux",
+			ignored:    true,
+		},
+		{
+			ignoreList: "appengine test",
+			goBuild:    "(mips || appengine) && linux",
+			ignored:    false,
+		},
+		{
+			ignoreList: "mips appengine",
+			goBuild:    "!(!mips || !appengine)",
+			ignored:    true,
+		},
+		{
+			ignoreList: "appengine",
+			goBuild:    "!(!mips && !appengine)",
+			ignored:    false,
+		},
+		{
+			ignoreList: "",
+			file:       []string{"linux"},
+			goBuild:    "!linux",
+			ignored:    true,
+		},
+		{
+			ignoreList: "test",
+			file:       []string{"test"},
+			goBuild:    "go1.8 && !appengine",
+			ignored:    true,
+		},
 	}
 
 	run := -1
@@ -101,7 +162,14 @@ func TestTagComplex(t *testing.T) {
 		for _, f := range item.file {
 			ts.AddFileTag(f)
 		}
-		ts.AddBuildTags(item.buildTags)
+		if len(item.goBuild) == 0 {
+			ts.AddBuildTags(item.buildTags)
+		} else {
+			if err := ts.AddBuildExpr(item.goBuild); err != nil {
+				t.Errorf("index %d: %v", index, err)
+				continue
+			}
+		}
 
 		ignored := ts.IgnoreItem(ignore...)
 
@@ -110,3 +178,77 @@ func TestTagComplex(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildExprInvalid(t *testing.T) {
+	for _, expr := range []string{"", "linux &&", "(linux", "linux)", "linux darwin", "linux & darwin", "!"} {
+		ts := &TagSet{}
+		if err := ts.AddBuildExpr(expr); err == nil {
+			t.Errorf("expected error for %q, got %v", expr, ts)
+		}
+	}
+}
+
+func TestGetFileTagsGoBuild(t *testing.T) {
+	list := []struct {
+		name    string
+		content string
+		ignore  string
+		ignored bool
+	}{
+		{
+			name:    "only_new.go",
+			content: "//go:build appengine && !go1.8\n\npackage a\n",
+			ignore:  "appengine",
+			ignored: true,
+		},
+		{
+			name:    "only_old.go",
+			content: "// +build appengine\n\npackage a\n",
+			ignore:  "appengine",
+			ignored: true,
+		},
+		{
+			name:    "both.go",
+			content: "//go:build linux || appengine\n// +build linux appengine\n\npackage a\n",
+			ignore:  "appengine",
+			ignored: false,
+		},
+		{
+			// The new form is used when the lines differ.
+			name:    "differ.go",
+			content: "//go:build !appengine\n// +build appengine\n\npackage a\n",
+			ignore:  "appengine",
+			ignored: false,
+		},
+		{
+			// The new form is only read before the package clause.
+			name:    "after.go",
+			content: "package a\n\n//go:build appengine\n",
+			ignore:  "appengine",
+			ignored: false,
+		},
+		{
+			name:    "invalid.go",
+			content: "//go:build appengine &&\n// +build appengine\n\npackage a\n",
+			ignore:  "appengine",
+			ignored: true,
+		},
+	}
+	dir, err := ioutil.TempDir("", "govendor-tags-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := &Context{}
+	for _, item := range list {
+		p := filepath.Join(dir, item.name)
+		writeFile(t, p, item.content)
+		tags, _, err := ctx.getFileTags(p, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tags.IgnoreItem(strings.Fields(item.ignore)...); got != item.ignored {
+			t.Errorf("%s: wanted ignored=%t, got %t: %v", item.name, item.ignored, got, tags)
+		}
+	}
+}
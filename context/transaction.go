@@ -0,0 +1,212 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kardianos/govendor/vendorfile"
+	"github.com/pkg/errors"
+)
+
+// vendorTx stages changes to the vendor folder so they are either all
+// applied or none are.
+//
+// The stage starts as a copy of the vendor folder made of hard links next
+// to the vendor folder. Files are never written in place, they are removed
+// first, so the original vendor folder is never modified. On commit the
+// vendor file is written to the stage and the stage replaces the vendor
+// folder.
+type vendorTx struct {
+	ctx   *Context
+	root  string // The vendor folder.
+	stage string // The staged copy of the vendor folder.
+
+	// Vendor file packages and their values before the transaction.
+	pkgs   []*vendorfile.Package
+	values []vendorfile.Package
+
+	manifest []*vendorfile.ManifestPackage
+}
+
+// stagePrefix starts the name of a stage folder.
+const stagePrefix = ".govendor-stage-"
+
+// beginTx starts staging changes to the vendor folder.
+func (ctx *Context) beginTx() (*vendorTx, error) {
+	root := filepath.Join(ctx.RootDir, ctx.VendorFolder)
+	stage, err := ioutil.TempDir(filepath.Dir(root), stagePrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "create stage folder")
+	}
+	tx := &vendorTx{
+		ctx:   ctx,
+		root:  root,
+		stage: stage,
+
+		pkgs:   make([]*vendorfile.Package, len(ctx.VendorFile.Package)),
+		values: make([]vendorfile.Package, len(ctx.VendorFile.Package)),
+	}
+	copy(tx.pkgs, ctx.VendorFile.Package)
+	if ctx.manifest != nil {
+		tx.manifest = append(tx.manifest, ctx.manifest.Package...)
+	}
+	for i, vp := range tx.pkgs {
+		tx.values[i] = *vp
+	}
+	err = linkTree(stage, root)
+	if err != nil {
+		os.RemoveAll(stage)
+		return nil, errors.Wrap(err, "stage vendor folder")
+	}
+	ctx.stage = tx
+	return tx, nil
+}
+
+// stagePath returns the staged location of p if p is in the vendor folder
+// and a transaction is in progress.
+func (ctx *Context) stagePath(p string) string {
+	tx := ctx.stage
+	if tx == nil || !pathInDir(p, tx.root) {
+		return p
+	}
+	rel, _ := filepath.Rel(tx.root, p)
+	return filepath.Join(tx.stage, rel)
+}
+
+// commit writes the vendor file and replaces the vendor folder with the
+// stage. If the vendor folder can't be replaced it is left unchanged.
+func (tx *vendorTx) commit() error {
+	ctx := tx.ctx
+	inVendor := pathInDir(ctx.VendorFilePath, tx.root)
+	if inVendor {
+		// Written to the stage.
+		err := ctx.WriteVendorFile()
+		if err != nil {
+			tx.rollback()
+			return err
+		}
+	}
+	ctx.stage = nil
+
+	old := tx.stage + ".old"
+	_, err := os.Stat(tx.root)
+	hasRoot := err == nil
+	if hasRoot {
+		err = os.Rename(tx.root, old)
+		if err != nil {
+			tx.rollback()
+			return errors.Wrap(err, "move vendor folder aside")
+		}
+	}
+	err = os.Rename(tx.stage, tx.root)
+	if err != nil {
+		if hasRoot {
+			os.Rename(old, tx.root)
+		}
+		tx.rollback()
+		return errors.Wrap(err, "move stage into vendor folder")
+	}
+	if hasRoot {
+		os.RemoveAll(old)
+	}
+	if !inVendor {
+		return ctx.WriteVendorFile()
+	}
+	ctx.vendorStamp = statStamp(ctx.VendorFilePath)
+	return nil
+}
+
+// recoverTx finishes a commit that was stopped, such as by a crash, after
+// the vendor folder was moved aside. If the vendor folder is missing it is
+// moved back and the stage is removed, otherwise the stage had replaced it
+// and the old vendor folder is removed.
+func recoverTx(root string) error {
+	parent := filepath.Dir(root)
+	fl, err := ioutil.ReadDir(parent)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, fi := range fl {
+		name := fi.Name()
+		if !fi.IsDir() || !strings.HasPrefix(name, stagePrefix) || !strings.HasSuffix(name, ".old") {
+			continue
+		}
+		old := filepath.Join(parent, name)
+		if _, err := os.Stat(root); err == nil {
+			os.RemoveAll(old)
+			continue
+		}
+		err = os.Rename(old, root)
+		if err != nil {
+			return errors.Wrap(err, "restore vendor folder")
+		}
+		os.RemoveAll(strings.TrimSuffix(old, ".old"))
+	}
+	return nil
+}
+
+// rollback discards the stage and restores the vendor file packages.
+// The vendor folder and vendor file on disk are left unchanged.
+func (tx *vendorTx) rollback() {
+	ctx := tx.ctx
+	ctx.stage = nil
+	os.RemoveAll(tx.stage)
+
+	for i, vp := range tx.pkgs {
+		*vp = tx.values[i]
+	}
+	ctx.VendorFile.Package = tx.pkgs
+	if ctx.manifest != nil {
+		ctx.manifest.Package = tx.manifest
+	}
+	ctx.dirty = true
+}
+
+func pathInDir(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// linkTree recreates the src folder tree in dest, with hard links to the
+// src files. If a hard link can't be made the file is copied.
+func linkTree(dest, src string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == src && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			if rel == "." {
+				return nil
+			}
+			return os.Mkdir(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		if os.Link(p, target) == nil {
+			return nil
+		}
+		return copyFile(target, p, nil)
+	})
+}
@@ -0,0 +1,563 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/tools/go/vcs"
+)
+
+// VCSCmd runs the commands of a version control system on the repos in
+// the cache. All commands are run through VCSCmd rather than vcs.Cmd so
+// the environment and logging can be controlled.
+type VCSCmd struct {
+	*vcs.Cmd
+
+	RevisionSyncCmd string // command to sync to a specific revision, defaults to TagSyncCmd
+	RemoteCmd       string // command to print the remote repo of an existing repo
+	VerifyCmd       string // command to check the integrity of an existing repo
+	AncestorCmd     string // command that succeeds if revision {a} is an ancestor of {b}
+
+	// Shallow copies only contain the revisions needed. They are not used
+	// if ShallowCreateCmd is empty.
+	ShallowCreateCmd []string // commands to create an empty repo in {dir} for {repo}
+	FetchRevisionCmd string   // command to fetch only revision {tag}
+	DeepenCmd        string   // command to fetch the full history and tags
+	ShallowFile      string   // file within a shallow repo that marks it as shallow
+
+	// A Subversion repo may not use the standard "trunk", "tags" layout.
+	// If LayoutCmd is set, it lists the folders at the repo root. Without
+	// a "tags" folder the repo has no tags, without a "trunk" folder the
+	// default tag is synced with FlatSyncDefault.
+	LayoutCmd       string
+	FlatSyncDefault string
+
+	// Env is added to the environment of each command. It may contain
+	// credentials and is never logged.
+	Env []string
+	// Args are added before the arguments of each command. They may
+	// contain credentials and are never logged.
+	Args []string
+
+	// Interrupt stops a running command when done. May be nil.
+	Interrupt gocontext.Context
+	// Timeout limits the time of each command, zero for no limit.
+	Timeout time.Duration
+}
+
+// partialSuffix is added to a repo folder name for the file that marks
+// a repo as being created. If the marker remains the create was abandoned.
+const partialSuffix = ".govendor-partial"
+
+// beginCreate marks the repo in dir as being created.
+func beginCreate(dir string) error {
+	f, err := os.Create(dir + partialSuffix)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// endCreate removes the create marker of the repo in dir, and the repo
+// if the create failed.
+func endCreate(dir string, err error) {
+	if err != nil {
+		os.RemoveAll(dir)
+	}
+	os.Remove(dir + partialSuffix)
+}
+
+// removeAbandoned removes the repo in dir if its create was abandoned,
+// and reports if it was removed.
+func removeAbandoned(dir string) bool {
+	if _, err := os.Stat(dir + partialSuffix); err != nil {
+		return false
+	}
+	endCreate(dir, errAbandoned)
+	return true
+}
+
+var errAbandoned = fmt.Errorf("abandoned create")
+
+// RevisionSync syncs the repo in dir to the given revision.
+func (vcsCmd *VCSCmd) RevisionSync(dir, revision string) error {
+	cmd := vcsCmd.RevisionSyncCmd
+	if len(cmd) == 0 {
+		cmd = vcsCmd.TagSyncCmd
+	}
+	return vcsCmd.run(dir, cmd, "tag", revision)
+}
+
+// Create creates a new copy of repo in dir. Unlike vcs.Cmd.Create, the
+// parent of dir need not exist and dir may exist if it is empty, as
+// some VCS refuse to create a repo in an existing directory.
+func (vcsCmd *VCSCmd) Create(dir, repo string) (err error) {
+	err = os.Remove(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(dir), 0700)
+	if err != nil {
+		return err
+	}
+	err = beginCreate(dir)
+	if err != nil {
+		return err
+	}
+	// Remove any partial copy so the create may be retried.
+	defer func() { endCreate(dir, err) }()
+	return vcsCmd.run(".", vcsCmd.CreateCmd, "dir", dir, "repo", repo)
+}
+
+// CreateAtRev creates a new copy of repo in dir at revision rev.
+func (vcsCmd *VCSCmd) CreateAtRev(dir, repo, rev string) error {
+	err := vcsCmd.Create(dir, repo)
+	if err != nil {
+		return err
+	}
+	return vcsCmd.RevisionSync(dir, rev)
+}
+
+// CreateShallow creates a copy of repo in dir that only contains revision
+// rev. If a shallow copy is not supported by the VCS or the remote, a full
+// copy is created. The repo is not synced to rev.
+func (vcsCmd *VCSCmd) CreateShallow(dir, repo, rev string) error {
+	if len(vcsCmd.ShallowCreateCmd) == 0 {
+		return vcsCmd.Create(dir, repo)
+	}
+	err := os.Remove(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(dir), 0700)
+	if err != nil {
+		return err
+	}
+	err = beginCreate(dir)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range vcsCmd.ShallowCreateCmd {
+		err = vcsCmd.run(".", cmd, "dir", dir, "repo", repo)
+		if err != nil {
+			endCreate(dir, err)
+			return err
+		}
+	}
+	err = vcsCmd.FetchRevision(dir, rev)
+	if err == nil {
+		endCreate(dir, nil)
+		return nil
+	}
+	endCreate(dir, err)
+	if _, is := err.(ErrInterrupted); is {
+		return err
+	}
+	// Some remotes do not allow fetching a single revision.
+	return vcsCmd.Create(dir, repo)
+}
+
+// FetchRevision fetches only revision rev into the shallow repo in dir.
+func (vcsCmd *VCSCmd) FetchRevision(dir, rev string) error {
+	if len(vcsCmd.FetchRevisionCmd) == 0 {
+		return fmt.Errorf("%s: unable to fetch a single revision", vcsCmd.Name)
+	}
+	// Failure is expected for some remotes, don't print the output.
+	_, err := vcsCmd.run1(dir, vcsCmd.FetchRevisionCmd, []string{"tag", rev}, false)
+	return err
+}
+
+// IsShallow reports if the repo in dir is a shallow copy.
+func (vcsCmd *VCSCmd) IsShallow(dir string) bool {
+	if len(vcsCmd.ShallowFile) == 0 {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, vcsCmd.ShallowFile))
+	return err == nil
+}
+
+// Deepen fetches the full history and tags if the repo in dir is a
+// shallow copy.
+func (vcsCmd *VCSCmd) Deepen(dir string) error {
+	if !vcsCmd.IsShallow(dir) {
+		return nil
+	}
+	return vcsCmd.run(dir, vcsCmd.DeepenCmd)
+}
+
+// IsAncestor reports if revision a is an ancestor of revision b in the
+// repo in dir.
+func (vcsCmd *VCSCmd) IsAncestor(dir, a, b string) (bool, error) {
+	if len(vcsCmd.AncestorCmd) == 0 {
+		return false, fmt.Errorf("%s: unable to compare revisions", vcsCmd.Name)
+	}
+	_, err := vcsCmd.run1(dir, vcsCmd.AncestorCmd, []string{"a", a, "b", b}, false)
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, is := err.(*exec.ExitError); is {
+		if status, is := exitErr.Sys().(syscall.WaitStatus); is && status.ExitStatus() == 1 {
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+// Download downloads any new changes for the repo in dir.
+func (vcsCmd *VCSCmd) Download(dir string) error {
+	return vcsCmd.run(dir, vcsCmd.DownloadCmd)
+}
+
+// DownloadRevision downloads the changes needed to sync the repo in dir
+// to revision rev. A shallow repo only fetches rev if possible.
+func (vcsCmd *VCSCmd) DownloadRevision(dir, rev string) error {
+	if !vcsCmd.IsShallow(dir) {
+		return vcsCmd.Download(dir)
+	}
+	if vcsCmd.FetchRevision(dir, rev) == nil {
+		return nil
+	}
+	return vcsCmd.Deepen(dir)
+}
+
+// Tags returns the list of available tags for the repo in dir.
+func (vcsCmd *VCSCmd) Tags(dir string) ([]string, error) {
+	folders, err := vcsCmd.layout(dir)
+	if err != nil {
+		return nil, err
+	}
+	if folders != nil && !folders["tags"] {
+		return nil, nil
+	}
+	var tags []string
+	for _, tc := range vcsCmd.TagCmd {
+		out, err := vcsCmd.runOutput(dir, tc.Cmd)
+		if err != nil {
+			return nil, err
+		}
+		re := regexp.MustCompile(`(?m-s)` + tc.Pattern)
+		for _, m := range re.FindAllStringSubmatch(string(out), -1) {
+			tags = append(tags, m[1])
+		}
+	}
+	return tags, nil
+}
+
+// TagSync syncs the repo in dir to the named tag, which is either a
+// tag returned by Tags or the empty string (the default tag).
+func (vcsCmd *VCSCmd) TagSync(dir, tag string) error {
+	if vcsCmd.TagSyncCmd == "" {
+		return nil
+	}
+	if tag != "" {
+		for _, tc := range vcsCmd.TagLookupCmd {
+			out, err := vcsCmd.runOutput(dir, tc.Cmd, "tag", tag)
+			if err != nil {
+				return err
+			}
+			re := regexp.MustCompile(`(?m-s)` + tc.Pattern)
+			m := re.FindStringSubmatch(string(out))
+			if len(m) > 1 {
+				tag = m[1]
+				break
+			}
+		}
+	}
+	if tag == "" && vcsCmd.TagSyncDefault != "" {
+		folders, err := vcsCmd.layout(dir)
+		if err != nil {
+			return err
+		}
+		if folders != nil && !folders["trunk"] {
+			return vcsCmd.run(dir, vcsCmd.FlatSyncDefault)
+		}
+		return vcsCmd.run(dir, vcsCmd.TagSyncDefault)
+	}
+	return vcsCmd.run(dir, vcsCmd.TagSyncCmd, "tag", tag)
+}
+
+// layout returns the folders at the root of the repo in dir, or nil if
+// the VCS has no LayoutCmd.
+func (vcsCmd *VCSCmd) layout(dir string) (map[string]bool, error) {
+	if len(vcsCmd.LayoutCmd) == 0 {
+		return nil, nil
+	}
+	out, err := vcsCmd.runOutput(dir, vcsCmd.LayoutCmd)
+	if err != nil {
+		return nil, err
+	}
+	folders := make(map[string]bool, 3)
+	re := regexp.MustCompile(`(?m-s)^(\S+)/$`)
+	for _, m := range re.FindAllStringSubmatch(string(out), -1) {
+		folders[m[1]] = true
+	}
+	return folders, nil
+}
+
+// Verify checks the integrity of the repo in dir.
+func (vcsCmd *VCSCmd) Verify(dir string) error {
+	if len(vcsCmd.VerifyCmd) == 0 {
+		return fmt.Errorf("%s: unable to verify repo", vcsCmd.Name)
+	}
+	out, err := vcsCmd.run1(dir, vcsCmd.VerifyCmd, nil, false)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v\n%s", vcsCmd.Cmd.Cmd, vcsCmd.VerifyCmd, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// RemoteRepo returns the repo the existing repo in dir was created from.
+func (vcsCmd *VCSCmd) RemoteRepo(dir string) (string, error) {
+	if len(vcsCmd.RemoteCmd) == 0 {
+		return "", fmt.Errorf("%s: unable to determine remote repo", vcsCmd.Name)
+	}
+	out, err := vcsCmd.runOutput(dir, vcsCmd.RemoteCmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (v *VCSCmd) run(dir string, cmd string, keyval ...string) error {
+	_, err := v.run1(dir, cmd, keyval, true)
+	return err
+}
+
+func (v *VCSCmd) runOutput(dir string, cmd string, keyval ...string) ([]byte, error) {
+	return v.run1(dir, cmd, keyval, true)
+}
+
+// run1 is the generalized implementation of run and runOutput.
+func (vcsCmd *VCSCmd) run1(dir string, cmdline string, keyval []string, verbose bool) ([]byte, error) {
+	v := vcsCmd.Cmd
+	m := make(map[string]string)
+	for i := 0; i < len(keyval); i += 2 {
+		m[keyval[i]] = keyval[i+1]
+	}
+	args := strings.Fields(cmdline)
+	for i, arg := range args {
+		args[i] = expand(m, arg)
+	}
+
+	_, err := exec.LookPath(v.Cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"go: missing %s command. See http://golang.org/s/gogetcmd\n",
+			v.Name)
+		return nil, err
+	}
+
+	runCtx := vcsCmd.Interrupt
+	if runCtx == nil {
+		runCtx = gocontext.Background()
+	}
+	if vcsCmd.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		runCtx, cancel = gocontext.WithTimeout(runCtx, vcsCmd.Timeout)
+		defer cancel()
+	}
+	cmd := exec.Command(v.Cmd, append(append([]string(nil), vcsCmd.Args...), args...)...)
+	cmd.Dir = dir
+	cmd.Env = mergeEnvLists(vcsCmd.Env, envForDir(cmd.Dir))
+	setProcessGroup(cmd)
+	if vcs.ShowCmd {
+		fmt.Printf("cd %s\n", dir)
+		fmt.Printf("%s %s\n", v.Cmd, strings.Join(redactArgs(args), " "))
+	}
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var out []byte
+	select {
+	case err = <-done:
+		out = buf.Bytes()
+	case <-runCtx.Done():
+		killProcess(cmd)
+		// A child process that could not be killed may keep the output
+		// open. Don't wait for it to close.
+		select {
+		case err = <-done:
+			out = buf.Bytes()
+		case <-time.After(time.Second):
+			err = runCtx.Err()
+		}
+	}
+	if err != nil && runCtx.Err() != nil {
+		if vcsCmd.Interrupt != nil && vcsCmd.Interrupt.Err() != nil {
+			return out, ErrInterrupted{vcsCmd.Interrupt.Err()}
+		}
+		return out, fmt.Errorf("%s %s: timed out after %v", v.Cmd, strings.Join(redactArgs(args), " "), vcsCmd.Timeout)
+	}
+	if err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "# cd %s; %s %s\n", dir, v.Cmd, strings.Join(redactArgs(args), " "))
+			os.Stderr.Write(out)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// redactArgs removes any password from URL arguments so they may be logged.
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = arg
+		if !strings.Contains(arg, "://") {
+			continue
+		}
+		u, err := url.Parse(arg)
+		if err != nil || u.User == nil {
+			continue
+		}
+		if _, has := u.User.Password(); has {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			out[i] = u.String()
+		}
+	}
+	return out
+}
+
+// expand rewrites s to replace {k} with match[k] for each key k in match.
+func expand(match map[string]string, s string) string {
+	for k, v := range match {
+		s = strings.Replace(s, "{"+k+"}", v, -1)
+	}
+	return s
+}
+
+// envForDir returns a copy of the environment
+// suitable for running in the given directory.
+// The environment is the current process's environment
+// but with an updated $PWD, so that an os.Getwd in the
+// child will be faster.
+func envForDir(dir string) []string {
+	env := os.Environ()
+	// Internally we only use rooted paths, so dir is rooted.
+	// Even if dir is not rooted, no harm done.
+	return mergeEnvLists([]string{"PWD=" + dir}, env)
+}
+
+// mergeEnvLists merges the two environment lists such that
+// variables with the same name in "in" replace those in "out".
+func mergeEnvLists(in, out []string) []string {
+NextVar:
+	for _, inkv := range in {
+		k := strings.SplitAfterN(inkv, "=", 2)[0]
+		for i, outkv := range out {
+			if strings.HasPrefix(outkv, k) {
+				out[i] = inkv
+				continue NextVar
+			}
+		}
+		out = append(out, inkv)
+	}
+	return out
+}
+
+// newVcsCmd returns the VCSCmd of cmd that is stopped by the Interrupt
+// and CommandTimeout of the context.
+func (ctx *Context) newVcsCmd(cmd *vcs.Cmd) *VCSCmd {
+	vcsCmd := updateVcsCmd(cmd)
+	vcsCmd.Interrupt = ctx.Interrupt
+	vcsCmd.Timeout = ctx.CommandTimeout
+	return vcsCmd
+}
+
+// updateVcsCmd sets the commands used to download and sync repos in the
+// cache. Each repo in the cache may be moved between revisions, so
+// syncing always discards the current working copy state.
+func updateVcsCmd(cmd *vcs.Cmd) *VCSCmd {
+	vcsCmd := &VCSCmd{Cmd: cmd}
+	switch cmd.Name {
+	case "Git":
+		cmd.TagSyncCmd = "reset --hard {tag}"
+		cmd.TagSyncDefault = "reset --hard origin/master"
+		cmd.DownloadCmd = "fetch"
+		vcsCmd.RemoteCmd = "config remote.origin.url"
+		vcsCmd.VerifyCmd = "fsck --no-progress"
+		vcsCmd.AncestorCmd = "merge-base --is-ancestor {a} {b}"
+		// Without blobs, only the files of checked out revisions are downloaded.
+		vcsCmd.ShallowCreateCmd = []string{
+			"init -q {dir}",
+			"-C {dir} remote add origin {repo}",
+		}
+		vcsCmd.FetchRevisionCmd = "fetch --depth=1 --filter=blob:none origin {tag}"
+		vcsCmd.DeepenCmd = "fetch --unshallow --tags origin"
+		vcsCmd.ShallowFile = filepath.Join(".git", "shallow")
+	case "Mercurial":
+		cmd.CreateCmd = "clone -U {repo} {dir}"
+		cmd.DownloadCmd = "pull"
+		cmd.TagCmd = []vcs.TagCmd{
+			{Cmd: "tags", Pattern: `^(\S+)`},
+			{Cmd: "branches", Pattern: `^(\S+)`},
+		}
+		cmd.TagSyncCmd = "update --clean -r {tag}"
+		cmd.TagSyncDefault = "update --clean default"
+		vcsCmd.RemoteCmd = "paths default"
+		vcsCmd.VerifyCmd = "verify"
+	case "Bazaar":
+		cmd.CreateCmd = "branch {repo} {dir}"
+		cmd.DownloadCmd = "pull --overwrite"
+		cmd.TagCmd = []vcs.TagCmd{{Cmd: "tags", Pattern: `^(\S+)`}}
+		cmd.TagSyncCmd = "update -r tag:{tag}"
+		cmd.TagSyncDefault = "update -r revno:-1"
+		// Revisions are recorded as revision numbers, see vcs.VcsBzr.
+		vcsCmd.RevisionSyncCmd = "update -r revno:{tag}"
+		vcsCmd.RemoteCmd = "config parent_location"
+		vcsCmd.VerifyCmd = "check"
+	case "Subversion":
+		// Tags and trunk are used if the repository root has the standard
+		// "trunk", "tags" layout.
+		cmd.CreateCmd = "checkout {repo} {dir}"
+		cmd.DownloadCmd = "update"
+		cmd.TagCmd = []vcs.TagCmd{{Cmd: "list ^/tags", Pattern: `^(\S+)/$`}}
+		cmd.TagSyncCmd = "switch --ignore-ancestry ^/tags/{tag}"
+		cmd.TagSyncDefault = "switch --ignore-ancestry ^/trunk"
+		vcsCmd.LayoutCmd = "list ^/"
+		vcsCmd.FlatSyncDefault = "update -r HEAD"
+		vcsCmd.RevisionSyncCmd = "update -r {tag}"
+		vcsCmd.RemoteCmd = "info --show-item url"
+		// The working copy has no history to check, ensure it can be read.
+		vcsCmd.VerifyCmd = "info"
+	}
+	return vcsCmd
+}
+
+var isSecureScheme = map[string]bool{
+	"https":   true,
+	"git+ssh": true,
+	"bzr+ssh": true,
+	"svn+ssh": true,
+	"ssh":     true,
+}
+
+func vcsIsSecure(repo string) bool {
+	u, err := url.Parse(repo)
+	if err != nil {
+		// If repo is not a URL, it's not secure.
+		return false
+	}
+	return isSecureScheme[u.Scheme]
+}
@@ -12,14 +12,17 @@ import (
 
 	"github.com/dchest/safefile"
 	"github.com/kardianos/govendor/vendorfile"
+	"github.com/pkg/errors"
 
 	os "github.com/kardianos/govendor/internal/vos"
 )
 
 // WriteVendorFile writes the current vendor file to the context location.
+// During Alter the vendor file is written to the staged vendor folder.
 func (ctx *Context) WriteVendorFile() (err error) {
+	vendorFilePath := ctx.stagePath(ctx.VendorFilePath)
 	perm := ros.FileMode(0666)
-	fi, err := os.Stat(ctx.VendorFilePath)
+	fi, err := os.Stat(vendorFilePath)
 	if err == nil {
 		perm = fi.Mode()
 	}
@@ -35,7 +38,7 @@ func (ctx *Context) WriteVendorFile() (err error) {
 	if err != nil {
 		return
 	}
-	dir, _ := filepath.Split(ctx.VendorFilePath)
+	dir, _ := filepath.Split(vendorFilePath)
 	err = os.MkdirAll(dir, 0777)
 	if err != nil {
 		return
@@ -46,16 +49,68 @@ func (ctx *Context) WriteVendorFile() (err error) {
 		vp.Add = false
 	}
 
-	err = safefile.WriteFile(ctx.VendorFilePath, buf.Bytes(), perm)
+	err = safefile.WriteFile(vendorFilePath, buf.Bytes(), perm)
 	if err == nil {
 		for _, vp := range ctx.VendorFile.Package {
 			vp.Add = false
 		}
 	}
+	if err == nil && ctx.manifest != nil {
+		err = ctx.writeManifest(perm)
+	}
+	if err == nil && ctx.stage == nil {
+		ctx.vendorStamp = statStamp(ctx.VendorFilePath)
+	}
 
 	return
 }
 
+// manifestPath returns the location of the manifest, relative to the
+// vendor file.
+func (ctx *Context) manifestPath() string {
+	return filepath.Join(filepath.Dir(ctx.VendorFilePath), filepath.FromSlash(ctx.VendorFile.Manifest))
+}
+
+// writeManifest writes the manifest with the vendor file packages.
+func (ctx *Context) writeManifest(perm ros.FileMode) error {
+	keep := make([]*vendorfile.ManifestPackage, 0, len(ctx.manifest.Package))
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove {
+			continue
+		}
+		if mp := ctx.manifest.Find(vp.Path); mp != nil {
+			keep = append(keep, mp)
+		}
+	}
+	ctx.manifest.Package = keep
+
+	buf := &bytes.Buffer{}
+	err := ctx.manifest.Marshal(buf)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	return safefile.WriteFile(ctx.stagePath(ctx.manifestPath()), buf.Bytes(), perm)
+}
+
+func readManifest(manifestPath string) (*vendorfile.Manifest, error) {
+	m := &vendorfile.Manifest{}
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	err = m.Unmarshal(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read manifest %q", manifestPath)
+	}
+	return m, nil
+}
+
 func readVendorFile(vendorRoot, vendorFilePath string) (*vendorfile.File, error) {
 	vf := &vendorfile.File{}
 	f, err := os.Open(vendorFilePath)
@@ -17,6 +17,13 @@ const (
 	MsgGet
 	MsgLicense
 	MsgShell
+	MsgCache
+	MsgUpgradeChecksum
+	MsgDiff
+	MsgPatch
+	MsgAudit
+	MsgCheck
+	MsgServe
 	MsgGovendorLicense
 	MsgGovendorVersion
 )
@@ -53,6 +60,20 @@ func (msg HelpMessage) String() string {
 		msgText = helpLicense
 	case MsgShell:
 		msgText = helpShell
+	case MsgCache:
+		msgText = helpCache
+	case MsgUpgradeChecksum:
+		msgText = helpUpgradeChecksum
+	case MsgDiff:
+		msgText = helpDiff
+	case MsgPatch:
+		msgText = helpPatch
+	case MsgAudit:
+		msgText = helpAudit
+	case MsgCheck:
+		msgText = helpCheck
+	case MsgServe:
+		msgText = helpServe
 	case MsgGovendorLicense:
 		msgText = msgGovendorLicenses
 	case MsgGovendorVersion:
@@ -15,8 +15,18 @@ import (
 var helpFull = `govendor (` + version + `): record dependencies and copy into vendor folder
 	-govendor-licenses    Show govendor's licenses.
 	-version              Show govendor version
+	-timeout 'duration'   Stop all remote operations after the duration,
+	                      such as "10m". Packages already synced are kept.
+	-cmd-timeout 'duration'
+	                      Stop each VCS command, such as a clone, after the
+	                      duration.
 	-cpuprofile 'file'    Writes a CPU profile to 'file' for debugging.
 	-memprofile 'file'    Writes a heap profile to 'file' for debugging.
+	-progress             Show the progress of each clone, download, copy and
+	                      other step with the time taken and bytes used.
+	-root-import 'path'   Import path of a project outside GOPATH. Otherwise
+	                      the vendor.json "rootPath" or the import comment of
+	                      a package file in the project root is used.
 
 Sub-Commands
 
@@ -34,6 +44,15 @@ Sub-Commands
 	license  List discovered licenses for the given status or import paths.
 	shell    Run a "shell" to make multiple sub-commands more efficient for large
 	             projects.
+	cache    List, verify, prune and clean the cache of fetched repositories.
+	diff     Show the differences between vendor packages and GOPATH, the recorded
+	             revision, or another version.
+	patch    Record local modifications of vendor packages as patches.
+	audit    Check vendor packages against an OSV vulnerability database.
+	check    Fail if the vendor folder or vendor.json has problems, for CI.
+	serve    Serve JSON-RPC requests over stdio or a unix socket.
+	upgrade-checksum  Add missing checksums to vendor.json packages that are
+	             unmodified in the vendor folder.
 
 	go tool commands that are wrapped:
 	  "+status" package selection may be used with them
@@ -69,6 +88,58 @@ Ignoring files with build tags, or excluding packages from being vendored:
 	If "foo/" appears in this field, then package "foo" and all its sub-packages
 	("foo/bar", …) will be excluded (but package "bar/foo" will not).
 	By default the init command adds the "test" tag to the ignore list.
+	The "prune" field is a space separated list of file and folder glob
+	patterns, such as "*.pb.gw.go examples/", that are not copied into any
+	package. A pattern ending in "/" only matches folders. A pattern with
+	another "/" matches the path within the package, otherwise it matches
+	the name at any depth. Each package may also have its own "prune" and
+	"ignore" fields. A package "ignore" of "!test" keeps test files for that
+	package only. Packages are checksummed with the same rules.
+
+Target platforms:
+	The "platforms" field of "vendor.json" is a space separated list of
+	"goos/goarch" targets, such as "linux/amd64 linux/arm64". Files whose name
+	suffix or build constraints can't build for any target are not copied and
+	their imports are not vendored. The "list" command shows the files excluded
+	from each package, and "status" the files excluded from vendor packages.
+
+Referenced files:
+	When a package is copied, the folders and quoted includes of its cgo
+	preambles, its "//go:embed" patterns and the patterns of the package
+	"assets" field in "vendor.json" are also copied, even from outside the
+	package folder. They are listed in the package "extraFiles" field and
+	included in the checksum. References that match no file are reported.
+
+Package checksums:
+	Each package in "vendor.json" records "checksumSHA1" and "checksumSHA256"
+	of its vendor folder files. The "requireChecksum" field is a space separated
+	list of the checksums a package must have to be verified by status and sync,
+	"sha1" by default. Set it to "sha256" or "sha1 sha256" after running
+	"govendor upgrade-checksum".
+	The "manifest" field names a file, relative to "vendor.json", that records
+	the hash of each vendored file, such as "vendor.manifest.json". It is
+	used by "govendor status -v" to list changed files. Run
+	"govendor upgrade-checksum" after setting it to record existing packages.
+
+Patches:
+	Patches of a package are kept in "vendor/_patches/<package path>/" and
+	listed in the package "patches" field of "vendor.json". They are applied in
+	order after add, update, fetch and sync copy the package. The command fails
+	if a patch does not apply. The checksum is of the patched package.
+
+Credentials for private repositories:
+	HTTPS credentials are read from the netrc file ($NETRC or ~/.netrc).
+	Per host settings are read from $GOVENDOR_AUTH or "govendor/auth.json"
+	in the user config directory, a list of:
+		{"host": "git.example.com", "ssh": true, "sshUser": "git",
+		 "helper": "git credential-store"}
+	"ssh" uses SSH rather than HTTPS for VCS commands. "helper" is a git
+	credential helper consulted before the netrc file. Credentials are
+	passed to git, hg and svn; bzr only reads its own authentication.conf.
+
+Exit codes:
+	0 success, 1 help shown, 2 error, 3 some remote operations failed
+	(sync, or fetch with -keep-going) while the others were completed.
 
 If using go1.5, ensure GO15VENDOREXPERIMENT=1 is set.
 
@@ -85,6 +156,7 @@ var helpList = `govendor list [options]  ( +status or import-path-filter )
 		-v           verbose listing, show dependencies of each package
 		-p           show file path to package instead of import path
 		-no-status   do not prefix status to list, package names only
+		-json        output the packages as JSON, see "doc/json.md"
 Examples:
 	$ govendor list -no-status +local
 	$ govendor list -p -no-status +local
@@ -103,6 +175,12 @@ var helpAdd = `govendor add [options] ( +status or import-path-filter )
 		The following may be replaced with something else in the future.
 		-short       if conflict, take short path
 		-long        if conflict, take long path
+
+	If the same package is found in more than one place, and neither the
+	vendor file nor -short or -long choose one, the places are shown with
+	their revision, uncommitted changes and file count to choose from.
+	The choice may then be used for the other conflicts with a package
+	from the same place.
 `
 
 var helpUpdate = `govendor update [options] ( +status or import-path-filter )
@@ -116,6 +194,12 @@ var helpUpdate = `govendor update [options] ( +status or import-path-filter )
 		The following may be replaced with something else in the future.
 		-short       if conflict, take short path
 		-long        if conflict, take long path
+
+	If the same package is found in more than one place, and neither the
+	vendor file nor -short or -long choose one, the places are shown with
+	their revision, uncommitted changes and file count to choose from.
+	The choice may then be used for the other conflicts with a package
+	from the same place.
 `
 
 var helpRemove = `govendor remove [options] ( +status or import-path-filter )
@@ -130,6 +214,8 @@ var helpFetch = `govendor fetch [options] ( +status or package-spec )
 	Options:
 		-tree        copy package(s) and all sub-folders under each package
 		-insecure    allow downloading over insecure connection
+		-keep-going  fetch all packages it can, then list any that failed
+		-retry       attempts for each network operation, default 3
 		-v           verbose mode
 `
 
@@ -138,12 +224,18 @@ var helpSync = `govendor sync
 	Options:
 		-n           dry run, print out action only
 		-insecure    allow downloading over insecure connection
+		-retry       attempts for each network operation, default 3
 		-v           verbose output
 `
 
-var helpStatus = `govendor status
+var helpStatus = `govendor status [options]
 	Shows any packages that are missing, out-of-date, or modified locally (according to the
 	checksum) and should be sync'ed.
+	Options:
+		-v           list the added (A), removed (D) and modified (M) files of each
+		             package, requires a file manifest
+		-json        output every vendor file package and its changes as JSON,
+		             see "doc/json.md"
 `
 
 var helpMigrate = `govendor migrate [` + strings.Join(migrate.SystemList(), ", ") + `]
@@ -162,11 +254,95 @@ var helpLicense = `govendor license [options] ( +status or package-spec )
 	Options:
 		-o           output to file name
 		-template    template file to use, input is "[]context.License"
+		-json        output the "[]context.License" as JSON, see "doc/json.md"
 `
 var helpShell = `govendor shell
 	Open a govendor "shell". Useful for faster queries on large projects.
+	Changed files are loaded again before each command. Tab completes
+	sub-commands, "+status" names and the import paths of the loaded packages.
 	Options:
 		-pprof-handler    expose a pprof HTTP server on the given address
+		-history          file to keep the command history in, default in the
+		                  user cache folder; set to "" to not keep it
+`
+
+var helpCache = `govendor cache ( list | verify | prune | clean ) [options] [repo]...
+	Manage the cache of repositories used by fetch and sync, found in
+	"$GOPATH/.cache/govendor". Repos default to all cached repos.
+	list      list repos with their size and the date last used
+	verify    check the integrity of each repo with its version control system
+	prune     remove repos not used within the -older-than duration
+	clean     remove the named repos, they are fetched again when needed
+	Options:
+		-older-than  duration for prune, such as "90d", "2w" or "36h"
+		-n           dry run, print what would be removed
+`
+
+var helpUpgradeChecksum = `govendor upgrade-checksum [options]
+	Add any missing checksums to the vendor.json packages. Only packages that
+	match their existing checksum are upgraded, modified packages are listed
+	and must be updated or sync'ed first.
+	Options:
+		-n           dry run, print the packages that would be upgraded
+`
+
+var helpDiff = `govendor diff [options] ( package-spec )...
+	Show a unified diff of each vendor package. By default the package is
+	compared to its GOPATH copy. If the package-spec has a version, such as
+	"pkg@v1.2", it is compared to that version in the cache. Only files that
+	would be copied into the vendor folder are compared.
+	The diff shows the changes "update" or "fetch" would make, except with -rev
+	where it shows the changes made locally since the recorded revision.
+	Options:
+		-rev         compare to the cached repo at the revision in vendor.json
+`
+
+var helpPatch = `govendor patch create [options] ( package-spec )...
+	Create a patch from the local modifications of each vendor package, compared
+	to the recorded revision with the existing patches applied. The patch is
+	written to "vendor/_patches/<package path>/" and added to "vendor.json".
+	Options:
+		-name        name of the patch file, default "local"
+`
+
+var helpAudit = `govendor audit [options]
+	Check each vendor package against the OSV advisories in a local folder of
+	JSON files. Version ranges are matched to the package "versionExact".
+	Commit ranges are matched to the package "revision" using the history of
+	the cached repo. Affected packages are listed with the advisory ID and the
+	first fixed version, and the command fails if any are found.
+	Options:
+		-db          advisory database folder, defaults to $GOVENDOR_OSV_DB
+`
+
+var helpCheck = `govendor check [options]
+	Run checks on the vendor folder and vendor.json and fail if any finds a
+	problem. The checks are:
+	missing      referenced packages that are not found
+	unused       vendor packages that are not used
+	external     packages used from GOPATH that should be vendored
+	files        vendor.json packages without files in the vendor folder
+	checksum     vendor packages that don't match the vendor.json checksum
+	uncommitted  vendor.json packages without a checksum, added uncommitted
+	duplicate    paths or origins listed more than once in vendor.json
+	revision     one repository pinned to several revisions
+	Options:
+		-checks      comma separated checks to run, defaults to all
+		-skip        comma separated checks not to run
+		-format      output as "text", "json" or "junit" XML, default "text"
+		-o           output to file name
+Examples:
+	$ govendor check -skip unused,revision
+	$ govendor check -format junit -o govendor-check.xml
+`
+
+var helpServe = `govendor serve [options]
+	Serve JSON-RPC 2.0 requests for editors and build tools, keeping the
+	packages loaded between requests like the shell. The methods are list,
+	status, add, update, remove, fetch, sync and license. Progress is sent
+	as "progress" notifications. See doc/serve.md for the params and results.
+	Options:
+		-socket      listen on the unix socket file instead of stdin and stdout
 `
 
 var msgGovendorVersion = version + `
@@ -0,0 +1,222 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the change to a single file in a unified diff.
+type FilePatch struct {
+	// Old and New are the file names with the first path element removed,
+	// such as the "a/" and "b/" prefixes. Empty if "/dev/null", when the
+	// file is created or removed.
+	Old, New string
+
+	Hunks []*Hunk
+}
+
+// Hunk is a single change in a file.
+type Hunk struct {
+	OldStart, OldCount int
+	NewStart, NewCount int
+
+	// Lines of the hunk, each starts with ' ', '-' or '+' and ends with a
+	// new line unless it is the last line of a file without one.
+	Lines []string
+}
+
+// Parse reads each file patch from the unified diff. Lines outside of the
+// file patches, such as git headers, are ignored.
+func Parse(b []byte) ([]*FilePatch, error) {
+	lines := splitLines(b)
+	var list []*FilePatch
+	var fp *FilePatch
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			fp = &FilePatch{
+				Old: patchName(line[4:]),
+				New: patchName(lines[i+1][4:]),
+			}
+			list = append(list, fp)
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if fp == nil {
+				return nil, fmt.Errorf("Line %d: hunk before file header", i+1)
+			}
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %v", i+1, err)
+			}
+			oldLeft, newLeft := h.OldCount, h.NewCount
+			for oldLeft > 0 || newLeft > 0 {
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("Line %d: hunk ends early", i)
+				}
+				line := lines[i]
+				if line == "\n" {
+					// Trailing space was removed from an empty context line.
+					line = " \n"
+				}
+				switch line[0] {
+				case ' ':
+					oldLeft--
+					newLeft--
+				case '-':
+					oldLeft--
+				case '+':
+					newLeft--
+				case '\\':
+					trimLastNewline(h)
+					continue
+				default:
+					return nil, fmt.Errorf("Line %d: unexpected line in hunk %q", i+1, strings.TrimSpace(line))
+				}
+				h.Lines = append(h.Lines, line)
+			}
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+				i++
+				trimLastNewline(h)
+			}
+			fp.Hunks = append(fp.Hunks, h)
+		case strings.HasPrefix(line, "Binary files "):
+			return nil, fmt.Errorf("Line %d: binary patches are not supported", i+1)
+		}
+	}
+	return list, nil
+}
+
+func trimLastNewline(h *Hunk) {
+	if len(h.Lines) == 0 {
+		return
+	}
+	last := len(h.Lines) - 1
+	h.Lines[last] = strings.TrimSuffix(h.Lines[last], "\n")
+}
+
+// patchName returns the file name of a header without the first path
+// element or any time stamp.
+func patchName(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// parseHunkHeader parses "@@ -1,3 +1,4 @@".
+func parseHunkHeader(line string) (*Hunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return nil, fmt.Errorf("invalid hunk header %q", strings.TrimSpace(line))
+	}
+	h := &Hunk{}
+	var err error
+	h.OldStart, h.OldCount, err = parseRange(fields[1][1:])
+	if err != nil {
+		return nil, err
+	}
+	h.NewStart, h.NewCount, err = parseRange(fields[2][1:])
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func parseRange(s string) (start, count int, err error) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		count, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk range %q", s)
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk range %q", s)
+	}
+	return start, count, nil
+}
+
+// Apply returns the content with each hunk applied. The lines a hunk
+// removes or keeps must match exactly, but may be found at a different
+// line than the hunk states.
+func (fp *FilePatch) Apply(content []byte) ([]byte, error) {
+	lines := splitLines(content)
+	out := make([]string, 0, len(lines))
+	next := 0   // Next line of content not yet copied to out.
+	offset := 0 // Lines the previous hunks were found from their stated position.
+	for index, h := range fp.Hunks {
+		var old, new []string
+		for _, line := range h.Lines {
+			switch line[0] {
+			case ' ':
+				old = append(old, line[1:])
+				new = append(new, line[1:])
+			case '-':
+				old = append(old, line[1:])
+			case '+':
+				new = append(new, line[1:])
+			}
+		}
+		want := h.OldStart - 1 + offset
+		if h.OldCount == 0 {
+			want = h.OldStart + offset
+		}
+		at := findLines(lines, old, want, next)
+		if at < 0 {
+			return nil, fmt.Errorf("hunk #%d does not apply at line %d", index+1, h.OldStart)
+		}
+		offset += at - want
+		out = append(out, lines[next:at]...)
+		out = append(out, new...)
+		next = at + len(old)
+	}
+	out = append(out, lines[next:]...)
+
+	buf := &bytes.Buffer{}
+	for _, line := range out {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
+
+// findLines returns the position of find in lines at or after min that is
+// nearest to want, or -1 if not found.
+func findLines(lines, find []string, want, min int) int {
+	match := func(at int) bool {
+		if at < min || at+len(find) > len(lines) {
+			return false
+		}
+		for i, line := range find {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for delta := 0; delta <= len(lines); delta++ {
+		if match(want - delta) {
+			return want - delta
+		}
+		if match(want + delta) {
+			return want + delta
+		}
+	}
+	return -1
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package diff creates line based unified diffs.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+type opType byte
+
+const (
+	opEqual  opType = ' '
+	opDelete opType = '-'
+	opInsert opType = '+'
+)
+
+// edit is a single line of the edit script. A and B are the line index
+// in each file, or for a line only in one file, the position in the other.
+type edit struct {
+	Op   opType
+	A, B int
+}
+
+// Unified writes the unified diff from a to b. Nothing is written if they
+// are the same.
+func Unified(w io.Writer, aName, bName string, a, b []byte) error {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	if isBinary(a) || isBinary(b) {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", aName, bName)
+		return err
+	}
+	al, bl := splitLines(a), splitLines(b)
+	edits := myers(al, bl)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		for start < len(edits) && edits[start].Op == opEqual {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		// Extend the hunk while changes are close together.
+		end := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].Op != opEqual {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*Context {
+				break
+			}
+		}
+		first := start - Context
+		if first < 0 {
+			first = 0
+		}
+		last := end + Context
+		if last > len(edits) {
+			last = len(edits)
+		}
+		writeHunk(buf, edits[first:last], al, bl)
+		start = last
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeHunk(buf *bytes.Buffer, hunk []edit, al, bl []string) {
+	var aCount, bCount int
+	for _, e := range hunk {
+		switch e.Op {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(hunk[0].A, aCount), hunkRange(hunk[0].B, bCount))
+	for _, e := range hunk {
+		line := ""
+		switch e.Op {
+		case opEqual, opDelete:
+			line = al[e.A]
+		case opInsert:
+			line = bl[e.B]
+		}
+		buf.WriteByte(byte(e.Op))
+		buf.WriteString(line)
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before it.
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits the content after each new line.
+func splitLines(b []byte) []string {
+	var lines []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			lines = append(lines, string(b))
+			break
+		}
+		lines = append(lines, string(b[:i+1]))
+		b = b[i+1:]
+	}
+	return lines
+}
+
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+// myers returns the shortest edit script from a to b.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back through the trace to find the path taken.
+	edits := make([]edit, 0, max)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{Op: opEqual, A: x, B: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{Op: opInsert, A: x, B: y})
+		} else {
+			x--
+			edits = append(edits, edit{Op: opDelete, A: x, B: y})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	list := []struct {
+		Name string
+		A, B string
+		Want string
+	}{
+		{
+			Name: "same",
+			A:    "a\nb\n",
+			B:    "a\nb\n",
+			Want: "",
+		},
+		{
+			Name: "change",
+			A:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			B:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			Want: `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			Name: "two hunks",
+			A:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			B:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			Want: `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`,
+		},
+		{
+			Name: "new file",
+			A:    "",
+			B:    "a\nb",
+			Want: `--- a
++++ b
+@@ -0,0 +1,2 @@
++a
++b
+\ No newline at end of file
+`,
+		},
+		{
+			Name: "removed file",
+			A:    "a\n",
+			B:    "",
+			Want: `--- a
++++ b
+@@ -1 +0,0 @@
+-a
+`,
+		},
+		{
+			Name: "binary",
+			A:    "a\x00",
+			B:    "b\x00",
+			Want: "Binary files a and b differ\n",
+		},
+	}
+	for _, item := range list {
+		buf := &bytes.Buffer{}
+		err := Unified(buf, "a", "b", []byte(item.A), []byte(item.B))
+		if err != nil {
+			t.Errorf("%s: %v", item.Name, err)
+			continue
+		}
+		if buf.String() != item.Want {
+			t.Errorf("%s: got\n%s\nwant\n%s", item.Name, buf.String(), item.Want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	list := []struct {
+		Name string
+		A, B string
+	}{
+		{Name: "change", A: "1\n2\n3\n4\n5\n6\n7\n8\n9\n", B: "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"},
+		{Name: "two hunks", A: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", B: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"},
+		{Name: "new file", A: "", B: "a\nb"},
+		{Name: "removed file", A: "a\n", B: ""},
+		{Name: "no newline", A: "a\nb", B: "a\nc\n"},
+		{Name: "empty lines", A: "a\n\n\nb\n", B: "a\n\nb\n"},
+	}
+	for _, item := range list {
+		buf := &bytes.Buffer{}
+		err := Unified(buf, "a/x.go", "b/x.go", []byte(item.A), []byte(item.B))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fpList, err := Parse(buf.Bytes())
+		if err != nil {
+			t.Errorf("%s: parse: %v", item.Name, err)
+			continue
+		}
+		if len(fpList) != 1 {
+			t.Errorf("%s: expected 1 file patch, got %d", item.Name, len(fpList))
+			continue
+		}
+		fp := fpList[0]
+		if item.A != "" && fp.Old != "x.go" || item.B != "" && fp.New != "x.go" {
+			t.Errorf("%s: unexpected names %q %q", item.Name, fp.Old, fp.New)
+		}
+		got, err := fp.Apply([]byte(item.A))
+		if err != nil {
+			t.Errorf("%s: apply: %v", item.Name, err)
+			continue
+		}
+		if string(got) != item.B {
+			t.Errorf("%s: got %q, want %q", item.Name, got, item.B)
+		}
+	}
+}
+
+func TestApplyOffsetAndConflict(t *testing.T) {
+	patch := `diff --git a/x.go b/x.go
+index 0000000..1111111 100644
+--- a/x.go
++++ b/x.go
+@@ -2,3 +2,3 @@
+ b
+-c
++C
+ d
+`
+	fpList, err := Parse([]byte(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := fpList[0]
+
+	// Lines were added before the hunk.
+	got, err := fp.Apply([]byte("new\nnew\na\nb\nc\nd\ne\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "new\nnew\na\nb\nC\nd\ne\n"; string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	_, err = fp.Apply([]byte("a\nb\nchanged\nd\n"))
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+}
@@ -15,3 +15,11 @@ func Join(parts ...string) string {
 func EvalSymlinks(path string) (string, error) {
 	return filepath.EvalSymlinks(path)
 }
+
+func Dir(path string) string {
+	return filepath.Dir(path)
+}
+
+func Clean(path string) string {
+	return filepath.Clean(path)
+}
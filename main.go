@@ -8,19 +8,29 @@ package main
 
 import (
 	"bytes"
+	gocontext "context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/kardianos/govendor/cliprompt"
+	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
+	"github.com/kardianos/govendor/prompt"
 	"github.com/kardianos/govendor/run"
+	"github.com/pkg/errors"
 )
 
 func main() {
-	prompt := &cliprompt.Prompt{}
+	// Only ask questions when there is a user to answer them, otherwise
+	// conflicts are left for -short and -long or reported.
+	var ask prompt.Prompt
+	if cliprompt.Interactive() {
+		ask = &cliprompt.Prompt{}
+	}
 
 	allArgs := os.Args
 
@@ -32,7 +42,20 @@ func main() {
 		}
 	}
 
-	msg, err := run.Run(os.Stdout, allArgs, prompt)
+	// The first interrupt stops remote operations and removes partial
+	// clones, a second one exits at once.
+	interrupt, cancel := gocontext.WithCancel(gocontext.Background())
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping. Interrupt again to exit now.")
+		cancel()
+		<-sig
+		os.Exit(130)
+	}()
+
+	msg, err := run.RunContext(interrupt, os.Stdout, allArgs, ask)
 	if err == flag.ErrHelp {
 		err = nil
 	}
@@ -43,6 +66,13 @@ func main() {
 	if len(msgText) > 0 {
 		fmt.Fprint(os.Stderr, msgText)
 	}
+	if _, is := errors.Cause(err).(context.ErrInterrupted); is {
+		os.Exit(130)
+	}
+	if _, is := err.(context.ErrRemoteFailures); is {
+		// Some remote operations failed, the others were completed.
+		os.Exit(3)
+	}
 	if err != nil {
 		os.Exit(2)
 	}
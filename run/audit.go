@@ -0,0 +1,66 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+func (r *runner) Audit(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	db := flags.String("db", os.Getenv("GOVENDOR_OSV_DB"), "advisory database folder")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgAudit, err
+	}
+	if len(*db) == 0 {
+		return help.MsgAudit, errors.New("missing advisory database folder, set -db or GOVENDOR_OSV_DB")
+	}
+	advisories, err := context.ReadAdvisories(*db)
+	if err != nil {
+		return help.MsgNone, err
+	}
+
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	found, err := ctx.Audit(advisories)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	if len(found) == 0 {
+		return help.MsgNone, nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	affected := make(map[string]bool, len(found))
+	for _, item := range found {
+		vp := item.Package
+		affected[vp.Path] = true
+		at := vp.VersionExact
+		if len(at) == 0 {
+			at = vp.Revision
+			if len(at) > 12 {
+				at = at[:12]
+			}
+		}
+		fixed := "no fix"
+		if len(item.Fixed) > 0 {
+			fixed = "fixed in " + item.Fixed
+		}
+		fmt.Fprintf(tw, "%s@%s\t%s\t%s\t%s\n", vp.Path, at, item.Advisory.ID, fixed, item.Advisory.Summary)
+	}
+	tw.Flush()
+	return help.MsgNone, fmt.Errorf("%d advisories affect %d package(s)", len(found), len(affected))
+}
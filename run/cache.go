@@ -0,0 +1,169 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+func (r *runner) Cache(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	if len(subCmdArgs) == 0 {
+		return help.MsgCache, errors.New("missing cache command")
+	}
+	flags := flag.NewFlagSet("cache", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	olderThan := flags.String("older-than", "", "prune repos not used within duration")
+	dryrun := flags.Bool("n", false, "dry run, print what would be removed")
+	err := flags.Parse(subCmdArgs[1:])
+	if err != nil {
+		return help.MsgCache, err
+	}
+	args := flags.Args()
+
+	ctx, err := r.NewContextWD(context.RootVendorOrWDOrFirstGOPATH)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	cache := ctx.Cache()
+
+	// Select the named repos or all repos.
+	selectRepos := func() ([]*context.CacheRepo, error) {
+		if len(args) == 0 {
+			return cache.List()
+		}
+		list := make([]*context.CacheRepo, 0, len(args))
+		for _, arg := range args {
+			repo, err := cache.Find(arg)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, repo)
+		}
+		return list, nil
+	}
+
+	switch subCmdArgs[0] {
+	default:
+		return help.MsgCache, fmt.Errorf("Unknown cache command %q", subCmdArgs[0])
+	case "list":
+		list, err := selectRepos()
+		if err != nil {
+			return help.MsgNone, err
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		var total int64
+		for _, repo := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", repo.Path, repo.VCS.Cmd, formatSize(repo.Size), repo.LastUsed.Format("2006-01-02"))
+			total += repo.Size
+		}
+		tw.Flush()
+		fmt.Fprintf(w, "%d repos, %s in %s\n", len(list), formatSize(total), cache.Root)
+	case "verify":
+		list, err := selectRepos()
+		if err != nil {
+			return help.MsgNone, err
+		}
+		failed := 0
+		for _, repo := range list {
+			err = cache.Verify(repo)
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "FAIL %s: %v\n", repo.Path, err)
+				continue
+			}
+			fmt.Fprintf(w, "ok   %s\n", repo.Path)
+		}
+		if failed > 0 {
+			return help.MsgNone, fmt.Errorf("%d of %d repos failed verification, use \"govendor cache clean\" to remove them", failed, len(list))
+		}
+	case "prune":
+		if len(*olderThan) == 0 {
+			return help.MsgCache, errors.New("missing -older-than duration")
+		}
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return help.MsgCache, err
+		}
+		list, err := selectRepos()
+		if err != nil {
+			return help.MsgNone, err
+		}
+		before := time.Now().Add(-age)
+		for _, repo := range list {
+			if !repo.LastUsed.Before(before) {
+				continue
+			}
+			fmt.Fprintf(w, "Remove %s (%s, last used %s)\n", repo.Path, formatSize(repo.Size), repo.LastUsed.Format("2006-01-02"))
+			if *dryrun {
+				continue
+			}
+			err = cache.Remove(repo)
+			if err != nil {
+				return help.MsgNone, err
+			}
+		}
+	case "clean":
+		if len(args) == 0 {
+			return help.MsgCache, errors.New("missing repo to clean")
+		}
+		list, err := selectRepos()
+		if err != nil {
+			return help.MsgNone, err
+		}
+		for _, repo := range list {
+			fmt.Fprintf(w, "Remove %s (%s)\n", repo.Path, formatSize(repo.Size))
+			if *dryrun {
+				continue
+			}
+			err = cache.Remove(repo)
+			if err != nil {
+				return help.MsgNone, err
+			}
+		}
+	}
+	return help.MsgNone, nil
+}
+
+// parseAge parses a duration that may also be in days ("90d") or weeks ("2w").
+func parseAge(s string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit == 0 {
+		return time.ParseDuration(s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid duration %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+func (r *runner) Check(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	checks := flags.String("checks", strings.Join(context.CheckList, ","), "comma separated checks to run")
+	skip := flags.String("skip", "", "comma separated checks to skip")
+	format := flags.String("format", "text", "output format: text, json or junit")
+	outputFilename := flags.String("o", "", "output file")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgCheck, err
+	}
+	switch *format {
+	case "text", "json", "junit":
+	default:
+		return help.MsgCheck, fmt.Errorf("Unknown format %q, expected text, json or junit", *format)
+	}
+	skipList := splitList(*skip)
+	var names []string
+	for _, name := range splitList(*checks) {
+		if !containsName(skipList, name) {
+			names = append(names, name)
+		}
+	}
+	for _, name := range skipList {
+		if !containsName(context.CheckList, name) {
+			return help.MsgCheck, fmt.Errorf("Unknown check %q, expected one of %s", name, strings.Join(context.CheckList, ", "))
+		}
+	}
+
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	results, err := ctx.RunChecks(names)
+	if err != nil {
+		return help.MsgCheck, err
+	}
+
+	output := w
+	if len(*outputFilename) > 0 {
+		f, err := os.Create(*outputFilename)
+		if err != nil {
+			return help.MsgNone, err
+		}
+		defer f.Close()
+		output = f
+	}
+	switch *format {
+	case "text":
+		err = writeCheckText(output, results)
+	case "json":
+		err = writeJSON(output, newJSONCheckList(results))
+	case "junit":
+		err = writeCheckJUnit(output, results)
+	}
+	if err != nil {
+		return help.MsgNone, err
+	}
+
+	var failed []string
+	problems := 0
+	for _, res := range results {
+		if len(res.Problems) > 0 {
+			failed = append(failed, res.Name)
+			problems += len(res.Problems)
+		}
+	}
+	if len(failed) > 0 {
+		return help.MsgNone, fmt.Errorf("check failed with %d problem(s): %s", problems, strings.Join(failed, ", "))
+	}
+	return help.MsgNone, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func containsName(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeCheckText(w io.Writer, results []context.CheckResult) error {
+	for _, res := range results {
+		result := "ok"
+		if len(res.Problems) > 0 {
+			result = "FAIL"
+		}
+		_, err := fmt.Fprintf(w, "%-4s %s\n", result, res.Name)
+		if err != nil {
+			return err
+		}
+		for _, p := range res.Problems {
+			_, err = fmt.Fprintf(w, "\t%s: %s\n", p.Package, p.Message)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonCheck is a check result of "check -format json".
+type jsonCheck struct {
+	Name     string             `json:"name"`
+	OK       bool               `json:"ok"`
+	Problems []jsonCheckProblem `json:"problems,omitempty"`
+}
+
+type jsonCheckProblem struct {
+	Package string `json:"package"`
+	Message string `json:"message"`
+}
+
+func newJSONCheckList(results []context.CheckResult) []jsonCheck {
+	list := make([]jsonCheck, len(results))
+	for i, res := range results {
+		list[i] = jsonCheck{Name: res.Name, OK: len(res.Problems) == 0}
+		for _, p := range res.Problems {
+			list[i].Problems = append(list[i].Problems, jsonCheckProblem{Package: p.Package, Message: p.Message})
+		}
+	}
+	return list
+}
+
+// The JUnit XML report has a test case for each check.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeCheckJUnit(w io.Writer, results []context.CheckResult) error {
+	suite := junitSuite{Name: "govendor check", Tests: len(results)}
+	for _, res := range results {
+		tc := junitCase{ClassName: "govendor.check", Name: res.Name}
+		if len(res.Problems) > 0 {
+			suite.Failures++
+			lines := make([]string, len(res.Problems))
+			for i, p := range res.Problems {
+				lines[i] = p.Package + ": " + p.Message
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d problem(s)", len(res.Problems)),
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err = enc.Encode(suite)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
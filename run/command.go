@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
@@ -126,7 +127,7 @@ func (r *runner) GoCmd(subcmd string, args []string) (help.HelpMessage, error) {
 					add := item.Local
 					// "go tool vet" takes dirs, not pkgs, so special case it.
 					if subcmd == "tool" && len(args) > 0 && args[0] == "vet" {
-						add = filepath.Join(ctx.RootGopath, add)
+						add = ctx.LocalDir(add)
 					}
 					otherArgs = append(otherArgs, add)
 				}
@@ -152,6 +153,8 @@ func (r *runner) GoCmd(subcmd string, args []string) (help.HelpMessage, error) {
 func (r *runner) Status(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
 	flags := flag.NewFlagSet("status", flag.ContinueOnError)
 	flags.SetOutput(nullWriter{})
+	verbose := flags.Bool("v", false, "list changed files")
+	asJSON := flags.Bool("json", false, "output as JSON")
 	err := flags.Parse(subCmdArgs)
 	if err != nil {
 		return help.MsgStatus, err
@@ -160,16 +163,90 @@ func (r *runner) Status(w io.Writer, subCmdArgs []string) (help.HelpMessage, err
 	if err != nil {
 		return help.MsgStatus, err
 	}
-	outOfDate, err := ctx.VerifyVendor()
+	outOfDate, err := ctx.VerifyVendorDetail()
 	if err != nil {
 		return help.MsgStatus, err
 	}
+	if *asJSON {
+		err = writeJSON(w, newJSONStatusList(ctx, outOfDate))
+		if err == nil && len(outOfDate) > 0 {
+			err = fmt.Errorf("status failed for %d package(s)", len(outOfDate))
+		}
+		return help.MsgNone, err
+	}
+	if len(ctx.Platforms()) > 0 {
+		printedHeader := false
+		for _, vp := range ctx.VendorFile.Package {
+			if vp.Remove || len(vp.PlatformExcluded) == 0 {
+				continue
+			}
+			if !printedHeader {
+				printedHeader = true
+				fmt.Fprintf(w, "The following files are excluded for platforms %s:\n", ctx.VendorFile.Platforms)
+			}
+			fmt.Fprintf(w, "\t%s\n", vp.Path)
+			for _, name := range strings.Fields(vp.PlatformExcluded) {
+				fmt.Fprintf(w, "\t\tX %s\n", name)
+			}
+		}
+	}
 	if len(outOfDate) == 0 {
 		return help.MsgNone, nil
 	}
 	fmt.Fprintf(w, "The following packages are missing or modified locally:\n")
-	for _, pkg := range outOfDate {
-		fmt.Fprintf(w, "\t%s\n", pkg.Path)
+	for _, m := range outOfDate {
+		fmt.Fprintf(w, "\t%s\n", m.Package.Path)
+		if !*verbose {
+			continue
+		}
+		if !m.HasManifest {
+			fmt.Fprintf(w, "\t\t(no file manifest)\n")
+			continue
+		}
+		for _, name := range m.Added {
+			fmt.Fprintf(w, "\t\tA %s\n", name)
+		}
+		for _, name := range m.Removed {
+			fmt.Fprintf(w, "\t\tD %s\n", name)
+		}
+		for _, name := range m.Modified {
+			fmt.Fprintf(w, "\t\tM %s\n", name)
+		}
 	}
 	return help.MsgNone, fmt.Errorf("status failed for %d package(s)", len(outOfDate))
 }
+
+func (r *runner) UpgradeChecksum(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("upgrade-checksum", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	dryrun := flags.Bool("n", false, "dry run")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgUpgradeChecksum, err
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return help.MsgUpgradeChecksum, err
+	}
+	upgraded, modified, err := ctx.UpgradeChecksum()
+	if err != nil {
+		return help.MsgNone, err
+	}
+	for _, pkg := range upgraded {
+		fmt.Fprintf(w, "Upgrade %s\n", pkg.Path)
+	}
+	if !*dryrun && len(upgraded) > 0 {
+		err = ctx.WriteVendorFile()
+		if err != nil {
+			return help.MsgNone, err
+		}
+	}
+	if len(modified) == 0 {
+		return help.MsgNone, nil
+	}
+	fmt.Fprintf(w, "The following packages are modified locally and were not upgraded:\n")
+	for _, pkg := range modified {
+		fmt.Fprintf(w, "\t%s\n", pkg.Path)
+	}
+	return help.MsgNone, fmt.Errorf("upgrade-checksum failed for %d package(s)", len(modified))
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/prompt"
+)
+
+// askConflicts asks the user to choose the package to vendor for each
+// unresolved conflict. After a choice the user may apply it to the
+// remaining conflicts with a package from the same place. If the user
+// cancels a choice an error is returned; cancelling the question to apply
+// it to the remaining conflicts applies it to none.
+func askConflicts(ctx *context.Context, cc []*context.Conflict, ask prompt.Prompt) error {
+	context.SortConflicts(cc)
+	for _, c := range cc {
+		if c.Resolved {
+			continue
+		}
+		cands, err := ctx.Candidates(c)
+		if err != nil {
+			return err
+		}
+		if len(cands) < 2 {
+			continue
+		}
+		header, rows := conflictView(cands)
+		q := &prompt.Question{
+			Prompt: fmt.Sprintf("Different packages for %s, choose one to vendor:\n     %s", c.Canonical, header),
+			Type:   prompt.TypeSelectOne,
+		}
+		for i, cand := range cands {
+			q.Options = append(q.Options, prompt.NewOption(cand.Local, rows[i], false))
+		}
+		resp, err := ask.Ask(q)
+		if err != nil {
+			return err
+		}
+		if resp == prompt.RespCancel {
+			return fmt.Errorf("cancelled choosing the package for %s", c.Canonical)
+		}
+		chosen := q.AnswerSingle(true)
+		var cand context.Candidate
+		for _, cand = range cands {
+			if cand.Local == chosen.Key().(string) {
+				break
+			}
+		}
+		c.OpIndex = cand.OpIndex
+		c.Resolved = true
+
+		similar := context.SimilarConflicts(cc, cand.Source)
+		if len(similar) == 0 {
+			continue
+		}
+		from := cand.Source
+		if len(from) == 0 {
+			from = "the canonical path"
+		}
+		q = &prompt.Question{
+			Prompt: fmt.Sprintf("Also use the package from %s for:", from),
+			Type:   prompt.TypeSelectMultiple,
+		}
+		for _, s := range similar {
+			opt := prompt.NewOption(s.Canonical, s.Canonical, false)
+			opt.Chosen = true
+			q.Options = append(q.Options, opt)
+		}
+		resp, err = ask.Ask(q)
+		if err != nil {
+			return err
+		}
+		if resp == prompt.RespCancel {
+			continue
+		}
+		apply := make([]*context.Conflict, 0, len(similar))
+		for _, opt := range q.AnswerMultiple(false) {
+			for _, s := range similar {
+				if s.Canonical == opt.Key().(string) {
+					apply = append(apply, s)
+				}
+			}
+		}
+		context.ResolveSimilar(apply, cand.Source)
+	}
+	return nil
+}
+
+// conflictView formats the candidates of a conflict as aligned columns.
+// It returns the header and a row for each candidate.
+func conflictView(cands []context.Candidate) (string, []string) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Local path\tRevision\tDirty\tFiles\n")
+	for _, cand := range cands {
+		rev := cand.Revision
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		if len(rev) == 0 {
+			rev = "-"
+		}
+		dirty := "no"
+		if cand.Dirty {
+			dirty = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", cand.Local, rev, dirty, cand.Files)
+	}
+	tw.Flush()
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	return lines[0], lines[1:]
+}
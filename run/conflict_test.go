@@ -0,0 +1,121 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+	"github.com/kardianos/govendor/prompt"
+)
+
+// conflictPrompt chooses the package vendored in co2 and keeps the default
+// selection of multiple options.
+type conflictPrompt struct {
+	asked  []prompt.Question
+	cancel bool
+}
+
+func (p *conflictPrompt) Ask(q *prompt.Question) (prompt.Response, error) {
+	if p.cancel {
+		return prompt.RespCancel, nil
+	}
+	if q.Type == prompt.TypeSelectOne {
+		for i := range q.Options {
+			if strings.HasPrefix(q.Options[i].Key().(string), "co2/") {
+				q.Options[i].Chosen = true
+			}
+		}
+	}
+	p.asked = append(p.asked, *q)
+	return prompt.RespAnswer, nil
+}
+
+func TestAskConflicts(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co4/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "co3/pk1", "co3/pk2"),
+	)
+	g.Setup("co4/pk1",
+		gt.File("a.go", "co3/pk1", "co3/pk2"),
+	)
+	g.Setup("co3/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "strings"),
+	)
+	g.Setup("co3/pk2",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co2")
+	Vendor(g, "co2 init", "init", "")
+	Vendor(g, "co2 add", "add +ext", "")
+	g.Setup("co3/pk1",
+		gt.File("c.go", "strings"),
+	)
+	g.In("co4")
+	Vendor(g, "co4 init", "init", "")
+	Vendor(g, "co4 add", "add +ext", "")
+	g.Check(os.RemoveAll(g.Path("co3")))
+
+	g.In("co1")
+	Vendor(g, "co1 init", "init", "")
+
+	// Cancelling a choice stops the command.
+	_, err := Run(&bytes.Buffer{}, []string{"testing", "update", "+ext"}, &conflictPrompt{cancel: true})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("expected cancelled error, got %v", err)
+	}
+	Vendor(g, "co1 list after cancel", "list +vendor", "")
+
+	ask := &conflictPrompt{}
+	_, err = Run(&bytes.Buffer{}, []string{"testing", "update", "+ext"}, ask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ask.asked) != 2 {
+		t.Fatalf("asked %d questions, want 2", len(ask.asked))
+	}
+
+	choose, similar := ask.asked[0], ask.asked[1]
+	if choose.Type != prompt.TypeSelectOne || len(choose.Options) != 2 {
+		t.Fatalf("choose question %+v", choose)
+	}
+	if !strings.Contains(choose.Prompt, "co3/pk1") || !strings.Contains(choose.Prompt, "Files") {
+		t.Errorf("choose prompt %q", choose.Prompt)
+	}
+	for _, opt := range choose.Options {
+		files := "3"
+		if strings.HasPrefix(opt.Key().(string), "co2/") {
+			files = "2"
+		}
+		if !strings.HasPrefix(opt.Prompt(), opt.Key().(string)) || !strings.HasSuffix(opt.Prompt(), files) {
+			t.Errorf("option %q", opt.Prompt())
+		}
+	}
+	if similar.Type != prompt.TypeSelectMultiple || len(similar.Options) != 1 || similar.Options[0].Key() != "co3/pk2" || !similar.Options[0].Chosen {
+		t.Fatalf("similar question %+v", similar)
+	}
+
+	// The package vendored in co2 is copied, without the file added later.
+	if _, err = os.Stat(filepath.Join(g.Current(), "vendor", "co3", "pk1", "c.go")); !os.IsNotExist(err) {
+		t.Errorf("co3/pk1 not vendored from co2: %v", err)
+	}
+	Vendor(g, "co1 list", "list", `
+ v  co2/pk1
+ v  co3/pk1
+ v  co3/pk2
+ v  co4/pk1
+ l  co1/pk1
+`)
+}
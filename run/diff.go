@@ -0,0 +1,56 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+func (r *runner) Diff(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	rev := flags.Bool("rev", false, "compare to the recorded revision")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgDiff, err
+	}
+	args := flags.Args()
+	if len(args) == 0 {
+		return help.MsgDiff, errors.New("missing package to compare")
+	}
+
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	cgp, err := currentGoPath(ctx)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	for _, arg := range args {
+		ps, err := pkgspec.Parse(cgp, arg)
+		if err != nil {
+			return help.MsgNone, err
+		}
+		source := context.DiffGopath
+		switch {
+		case ps.HasVersion:
+			source = context.DiffVersion
+		case *rev:
+			source = context.DiffRevision
+		}
+		err = ctx.Diff(w, ps, source)
+		if err != nil {
+			return help.MsgNone, err
+		}
+	}
+	return help.MsgNone, nil
+}
@@ -128,7 +128,7 @@ func currentGoPath(ctx *context.Context) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	wdpath := pathos.FileTrimPrefix(wd, ctx.RootGopath)
+	wdpath := ctx.DirImportPath(wd)
 	wdpath = pathos.SlashToFilepath(wdpath)
 	wdpath = strings.Trim(wdpath, "/")
 	return wdpath, nil
@@ -0,0 +1,159 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/vendorfile"
+)
+
+// The types below are the "-json" output of list, status and license.
+// They are documented in "doc/json.md". Fields may be added, but existing
+// fields are not renamed or removed.
+
+// jsonStatus is the status of a package.
+type jsonStatus struct {
+	Type     string `json:"type"`     // unknown, package, program
+	Location string `json:"location"` // unknown, notfound, std, local, external, vendor
+	Presence string `json:"presence"` // unknown, found, missing, unused, tree, excluded
+}
+
+// jsonVendor is the vendor file record of a package.
+type jsonVendor struct {
+	Origin         string `json:"origin,omitempty"`
+	Tree           bool   `json:"tree,omitempty"`
+	Revision       string `json:"revision,omitempty"`
+	RevisionTime   string `json:"revisionTime,omitempty"`
+	Version        string `json:"version,omitempty"`
+	VersionExact   string `json:"versionExact,omitempty"`
+	ChecksumSHA1   string `json:"checksumSHA1,omitempty"`
+	ChecksumSHA256 string `json:"checksumSHA256,omitempty"`
+}
+
+// jsonListItem is a package of "list -json".
+type jsonListItem struct {
+	Status           jsonStatus  `json:"status"`
+	Path             string      `json:"path"`
+	Local            string      `json:"local"`
+	Vendor           *jsonVendor `json:"vendor,omitempty"`
+	PlatformExcluded []string    `json:"platformExcluded,omitempty"`
+	ImportedBy       []string    `json:"importedBy,omitempty"`
+}
+
+// jsonStatusItem is a vendor file package of "status -json".
+type jsonStatusItem struct {
+	Path             string     `json:"path"`
+	Vendor           jsonVendor `json:"vendor"`
+	Changed          bool       `json:"changed"`
+	HasManifest      bool       `json:"hasManifest"`
+	Added            []string   `json:"added,omitempty"`
+	Removed          []string   `json:"removed,omitempty"`
+	Modified         []string   `json:"modified,omitempty"`
+	PlatformExcluded []string   `json:"platformExcluded,omitempty"`
+}
+
+var (
+	jsonTypeName = map[context.StatusType]string{
+		context.TypeUnknown: "unknown",
+		context.TypePackage: "package",
+		context.TypeProgram: "program",
+	}
+	jsonLocationName = map[context.StatusLocation]string{
+		context.LocationUnknown:  "unknown",
+		context.LocationNotFound: "notfound",
+		context.LocationStandard: "std",
+		context.LocationLocal:    "local",
+		context.LocationExternal: "external",
+		context.LocationVendor:   "vendor",
+	}
+	jsonPresenceName = map[context.StatusPresence]string{
+		context.PresenceUnknown:  "unknown",
+		context.PresenceFound:    "found",
+		context.PresenceMissing:  "missing",
+		context.PresenceUnused:   "unused",
+		context.PresenceTree:     "tree",
+		context.PresenceExcluded: "excluded",
+	}
+)
+
+func newJSONStatus(s context.Status) jsonStatus {
+	return jsonStatus{
+		Type:     jsonTypeName[s.Type],
+		Location: jsonLocationName[s.Location],
+		Presence: jsonPresenceName[s.Presence],
+	}
+}
+
+func newJSONVendor(vp *vendorfile.Package) jsonVendor {
+	return jsonVendor{
+		Origin:         vp.Origin,
+		Tree:           vp.Tree,
+		Revision:       vp.Revision,
+		RevisionTime:   vp.RevisionTime,
+		Version:        vp.Version,
+		VersionExact:   vp.VersionExact,
+		ChecksumSHA1:   vp.ChecksumSHA1,
+		ChecksumSHA256: vp.ChecksumSHA256,
+	}
+}
+
+func newJSONListItem(ctx *context.Context, item context.StatusItem, verbose bool) jsonListItem {
+	ji := jsonListItem{
+		Status:           newJSONStatus(item.Status),
+		Path:             item.Pkg.Path,
+		Local:            item.Local,
+		PlatformExcluded: item.PlatformExcluded,
+	}
+	if item.Status.Location == context.LocationVendor {
+		if vp := ctx.VendorFilePackagePath(item.Pkg.Path); vp != nil {
+			v := newJSONVendor(vp)
+			ji.Vendor = &v
+		}
+	}
+	if verbose {
+		for _, imp := range item.ImportedBy {
+			ji.ImportedBy = append(ji.ImportedBy, imp.Local)
+		}
+	}
+	return ji
+}
+
+func newJSONStatusList(ctx *context.Context, outOfDate []*context.VendorMismatch) []jsonStatusItem {
+	mismatch := make(map[*vendorfile.Package]*context.VendorMismatch, len(outOfDate))
+	for _, m := range outOfDate {
+		mismatch[m.Package] = m
+	}
+	list := make([]jsonStatusItem, 0, len(ctx.VendorFile.Package))
+	for _, vp := range ctx.VendorFile.Package {
+		if vp.Remove || len(vp.Path) == 0 {
+			continue
+		}
+		ji := jsonStatusItem{
+			Path:             vp.Path,
+			Vendor:           newJSONVendor(vp),
+			PlatformExcluded: strings.Fields(vp.PlatformExcluded),
+		}
+		if m, found := mismatch[vp]; found {
+			ji.Changed = true
+			ji.HasManifest = m.HasManifest
+			ji.Added = m.Added
+			ji.Removed = m.Removed
+			ji.Modified = m.Modified
+		}
+		list = append(list, ji)
+	}
+	return list
+}
+
+// writeJSON writes v as indented JSON followed by a new line.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
@@ -28,6 +28,7 @@ func (r *runner) License(w io.Writer, subCmdArgs []string) (help.HelpMessage, er
 	flags.SetOutput(nullWriter{})
 	outputFilename := flags.String("o", "", "output")
 	templateFilename := flags.String("template", "", "custom template file")
+	asJSON := flags.Bool("json", false, "output as JSON")
 	err := flags.Parse(subCmdArgs)
 	if err != nil {
 		return help.MsgLicense, err
@@ -74,16 +75,29 @@ func (r *runner) License(w io.Writer, subCmdArgs []string) (help.HelpMessage, er
 		insertListToAllNot(&f.Status, all)
 	}
 
-	list, err := ctx.Status()
+	licenseList, err := findLicenses(ctx, f)
 	if err != nil {
 		return help.MsgNone, err
 	}
-	var licenseList context.LicenseSort
+
+	if *asJSON {
+		return help.MsgNone, writeJSON(output, []context.License(licenseList))
+	}
+	return help.MsgNone, t.Execute(output, licenseList)
+}
+
+// findLicenses returns the licenses of Go and the packages that match
+// the filter, sorted by path.
+func findLicenses(ctx *context.Context, f filter) (context.LicenseSort, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
 	var lmap = make(map[string]context.License, 9)
 
 	err = context.LicenseDiscover(filepath.Clean(filepath.Join(ctx.Goroot, "..")), ctx.Goroot, " go", lmap)
 	if err != nil {
-		return help.MsgNone, fmt.Errorf("Failed to discover license for Go %q %v", ctx.Goroot, err)
+		return nil, fmt.Errorf("Failed to discover license for Go %q %v", ctx.Goroot, err)
 	}
 
 	for _, item := range list {
@@ -93,15 +107,15 @@ func (r *runner) License(w io.Writer, subCmdArgs []string) (help.HelpMessage, er
 		if len(f.Import) != 0 && f.FindImport(item) == nil {
 			continue
 		}
-		err = context.LicenseDiscover(ctx.RootGopath, filepath.Join(ctx.RootGopath, item.Local), "", lmap)
+		err = context.LicenseDiscover(ctx.RootGopath, ctx.LocalDir(item.Local), "", lmap)
 		if err != nil {
-			return help.MsgNone, fmt.Errorf("Failed to discover license for %q %v", item.Local, err)
+			return nil, fmt.Errorf("Failed to discover license for %q %v", item.Local, err)
 		}
 	}
+	licenseList := make(context.LicenseSort, 0, len(lmap))
 	for _, l := range lmap {
 		licenseList = append(licenseList, l)
 	}
 	sort.Sort(licenseList)
-
-	return help.MsgNone, t.Execute(output, licenseList)
+	return licenseList, nil
 }
@@ -21,6 +21,7 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	verbose := listFlags.Bool("v", false, "verbose")
 	asFilePath := listFlags.Bool("p", false, "show file path to package instead of import path")
 	noStatus := listFlags.Bool("no-status", false, "do not show the status")
+	asJSON := listFlags.Bool("json", false, "output as JSON")
 	err := listFlags.Parse(subCmdArgs)
 	if err != nil {
 		return help.MsgList, err
@@ -47,41 +48,17 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 		insertListToAllNot(&f.Status, all)
 	}
 
-	list, err := ctx.Status()
+	list, err := listItems(ctx, f, *verbose)
 	if err != nil {
 		return help.MsgNone, err
 	}
 
-	// If not verbose, remove any entries that will just confuse people.
-	// For example, one package may reference pkgA inside vendor, another
-	// package may reference pkgA outside vendor, resulting in both a
-	// external reference and a vendor reference.
-	// In the above case, remove the external reference.
-	if !*verbose {
-		next := make([]context.StatusItem, 0, len(list))
-		for checkIndex, check := range list {
-			if check.Status.Location != context.LocationExternal {
-				next = append(next, check)
-				continue
-			}
-			found := false
-			for lookIndex, look := range list {
-				if checkIndex == lookIndex {
-					continue
-				}
-				if check.Pkg.Path != look.Pkg.Path {
-					continue
-				}
-				if look.Status.Location == context.LocationVendor {
-					found = true
-					break
-				}
-			}
-			if !found {
-				next = append(next, check)
-			}
+	if *asJSON {
+		out := make([]jsonListItem, 0, len(list))
+		for _, item := range list {
+			out = append(out, newJSONListItem(ctx, item, *verbose))
 		}
-		list = next
+		return help.MsgNone, writeJSON(w, out)
 	}
 
 	formatSame := "%[1]v %[2]s\t%[3]s\t%[4]s\n"
@@ -99,13 +76,6 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
 	defer tw.Flush()
 	for _, item := range list {
-		if !f.HasStatus(item) {
-			continue
-		}
-		if len(f.Import) != 0 && f.FindImport(item) == nil {
-			continue
-		}
-
 		var path string
 		if *asFilePath {
 			path = item.Pkg.FilePath
@@ -118,6 +88,9 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 		} else {
 			fmt.Fprintf(tw, formatDifferent, item.Status, path, strings.TrimPrefix(item.Local, ctx.RootImportPath), item.Pkg.Version, item.VersionExact)
 		}
+		if len(item.PlatformExcluded) > 0 && !*noStatus {
+			fmt.Fprintf(tw, "    excluded for platforms: %s\n", strings.Join(item.PlatformExcluded, " "))
+		}
 		if *verbose {
 			for i, imp := range item.ImportedBy {
 				if i != len(item.ImportedBy)-1 {
@@ -130,3 +103,55 @@ func (r *runner) List(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	}
 	return help.MsgNone, nil
 }
+
+// listItems returns the packages that match the filter.
+func listItems(ctx *context.Context, f filter, verbose bool) ([]context.StatusItem, error) {
+	list, err := ctx.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	// If not verbose, remove any entries that will just confuse people.
+	// For example, one package may reference pkgA inside vendor, another
+	// package may reference pkgA outside vendor, resulting in both a
+	// external reference and a vendor reference.
+	// In the above case, remove the external reference.
+	if !verbose {
+		next := make([]context.StatusItem, 0, len(list))
+		for checkIndex, check := range list {
+			if check.Status.Location != context.LocationExternal {
+				next = append(next, check)
+				continue
+			}
+			found := false
+			for lookIndex, look := range list {
+				if checkIndex == lookIndex {
+					continue
+				}
+				if check.Pkg.Path != look.Pkg.Path {
+					continue
+				}
+				if look.Status.Location == context.LocationVendor {
+					found = true
+					break
+				}
+			}
+			if !found {
+				next = append(next, check)
+			}
+		}
+		list = next
+	}
+
+	next := make([]context.StatusItem, 0, len(list))
+	for _, item := range list {
+		if !f.HasStatus(item) {
+			continue
+		}
+		if len(f.Import) != 0 && f.FindImport(item) == nil {
+			continue
+		}
+		next = append(next, item)
+	}
+	return next, nil
+}
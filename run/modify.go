@@ -63,6 +63,13 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 	long := listFlags.Bool("long", false, "choose the long path")
 	tree := listFlags.Bool("tree", false, "copy all folders including and under selected folder")
 	insecure := listFlags.Bool("insecure", false, "allow insecure network updates")
+	// Only fetch makes network operations.
+	keepGoing, retry := new(bool), new(int)
+	*retry = context.DefaultRetry.Attempts
+	if mod == context.Fetch {
+		listFlags.BoolVar(keepGoing, "keep-going", false, "continue fetching after a remote failure")
+		listFlags.IntVar(retry, "retry", context.DefaultRetry.Attempts, "attempts for each network operation")
+	}
 	uncommitted := listFlags.Bool("uncommitted", false, "allows adding uncommitted changes. Doesn't update revision or checksum")
 	err = listFlags.Parse(subCmdArgs)
 	if err != nil {
@@ -83,6 +90,8 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 		ctx.Logger = w
 	}
 	ctx.Insecure = *insecure
+	ctx.KeepGoing = *keepGoing
+	ctx.Retry.Attempts = *retry
 	cgp, err := currentGoPath(ctx)
 	if err != nil {
 		return msg, err
@@ -92,40 +101,17 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 		return msg, err
 	}
 
-	mops := make([]context.ModifyOption, 0, 3)
-	if *uncommitted {
-		mops = append(mops, context.Uncommitted)
-	}
-	if *tree {
-		mops = append(mops, context.IncludeTree)
-	}
-
-	// Add explicit imports.
-	for _, imp := range f.Import {
-		err = ctx.ModifyImport(imp, mod, mops...)
-		if err != nil {
-			return help.MsgNone, err
-		}
-	}
-	err = ctx.ModifyStatus(f.Status, mod, mops...)
+	err = planModify(ctx, f, mod, modifyOptions{
+		Short:       *short,
+		Long:        *long,
+		Tree:        *tree,
+		Uncommitted: *uncommitted,
+		Ask:         ask,
+	})
 	if err != nil {
 		return help.MsgNone, err
 	}
 
-	// Auto-resolve package conflicts.
-	conflicts := ctx.Check()
-	conflicts = ctx.ResolveAutoVendorFileOrigin(conflicts)
-	if *long {
-		conflicts = context.ResolveAutoLongestPath(conflicts)
-	}
-	if *short {
-		conflicts = context.ResolveAutoShortestPath(conflicts)
-	}
-	ctx.ResloveApply(conflicts)
-
-	// TODO: loop through conflicts to see if there are any remaining conflicts.
-	// Print out any here.
-
 	if *dryrun {
 		for _, op := range ctx.Operation {
 			switch op.Type {
@@ -143,19 +129,66 @@ func (r *runner) Modify(w io.Writer, subCmdArgs []string, mod context.Modify, as
 		return help.MsgNone, nil
 	}
 
-	// Write intent, make the changes, then record any checksums or recursive info.
-	err = ctx.WriteVendorFile()
-	if err != nil {
-		return help.MsgNone, err
+	err = applyModify(ctx)
+	printUnresolved(w, ctx)
+	return help.MsgNone, err
+}
+
+// modifyOptions choose how add, update, remove and fetch select packages.
+type modifyOptions struct {
+	Short       bool // Resolve conflicts with the shortest path.
+	Long        bool // Resolve conflicts with the longest path.
+	Tree        bool // Include all folders under the package.
+	Uncommitted bool // Allow uncommitted changes.
+
+	Ask prompt.Prompt // Asks to resolve the remaining conflicts, if set.
+}
+
+// planModify adds the operations that modify the packages of the filter
+// to the context and resolves any package conflicts, asking the user for
+// the conflicts that remain if opt.Ask is set.
+func planModify(ctx *context.Context, f filter, mod context.Modify, opt modifyOptions) error {
+	mops := make([]context.ModifyOption, 0, 3)
+	if opt.Uncommitted {
+		mops = append(mops, context.Uncommitted)
 	}
-	// Write out vendor file and do change.
-	err = ctx.Alter()
-	vferr := ctx.WriteVendorFile()
+	if opt.Tree {
+		mops = append(mops, context.IncludeTree)
+	}
+
+	// Add explicit imports.
+	for _, imp := range f.Import {
+		err := ctx.ModifyImport(imp, mod, mops...)
+		if err != nil {
+			return err
+		}
+	}
+	err := ctx.ModifyStatus(f.Status, mod, mops...)
 	if err != nil {
-		return help.MsgNone, err
+		return err
 	}
-	if vferr != nil {
-		return help.MsgNone, vferr
+
+	// Auto-resolve package conflicts.
+	conflicts := ctx.Check()
+	conflicts = ctx.ResolveAutoVendorFileOrigin(conflicts)
+	if opt.Long {
+		conflicts = context.ResolveAutoLongestPath(conflicts)
+	}
+	if opt.Short {
+		conflicts = context.ResolveAutoShortestPath(conflicts)
 	}
-	return help.MsgNone, nil
+	if opt.Ask != nil {
+		err = askConflicts(ctx, conflicts, opt.Ask)
+		if err != nil {
+			return err
+		}
+	}
+	ctx.ResloveApply(conflicts)
+	return nil
+}
+
+// applyModify makes the planned changes. The vendor file is only written if
+// they succeed, or if only some remote operations failed with KeepGoing.
+func applyModify(ctx *context.Context) error {
+	return ctx.Alter()
 }
@@ -0,0 +1,59 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+	"github.com/kardianos/govendor/pkgspec"
+)
+
+func (r *runner) Patch(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	if len(subCmdArgs) == 0 {
+		return help.MsgPatch, errors.New("missing patch command")
+	}
+	flags := flag.NewFlagSet("patch", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	name := flags.String("name", "local", "patch name")
+	err := flags.Parse(subCmdArgs[1:])
+	if err != nil {
+		return help.MsgPatch, err
+	}
+	args := flags.Args()
+
+	switch subCmdArgs[0] {
+	default:
+		return help.MsgPatch, fmt.Errorf("Unknown patch command %q", subCmdArgs[0])
+	case "create":
+	}
+	if len(args) == 0 {
+		return help.MsgPatch, errors.New("missing package to create a patch for")
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return checkNewContextError(err)
+	}
+	cgp, err := currentGoPath(ctx)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	for _, arg := range args {
+		ps, err := pkgspec.Parse(cgp, arg)
+		if err != nil {
+			return help.MsgNone, err
+		}
+		patchName, err := ctx.CreatePatch(ps, *name)
+		if err != nil {
+			return help.MsgNone, err
+		}
+		fmt.Fprintf(w, "Created patch %s/%s/%s\n", context.PatchFolder, ps.Path, patchName)
+	}
+	return help.MsgNone, ctx.WriteVendorFile()
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kardianos/govendor/context"
+)
+
+// progress writes a line for each finished step, and for the start of
+// steps that use the network.
+type progress struct {
+	w io.Writer
+}
+
+func (p progress) Event(e context.Event) {
+	name := e.Package
+	if len(name) == 0 {
+		name = e.Path
+	}
+	if len(e.Revision) > 0 {
+		name += "@" + e.Revision
+	}
+	if !e.Done {
+		switch e.Kind {
+		case context.EventResolve, context.EventClone, context.EventDownload, context.EventTag:
+			fmt.Fprintf(p.w, "%-8s %s ...\n", e.Kind, name)
+		}
+		return
+	}
+	// Duration.Round needs Go 1.9.
+	elapsed := (e.Elapsed + time.Millisecond/2) / time.Millisecond * time.Millisecond
+	if e.Err != nil {
+		fmt.Fprintf(p.w, "%-8s %s failed after %v\n", e.Kind, name, elapsed)
+		return
+	}
+	switch e.Kind {
+	case context.EventResolve:
+		fmt.Fprintf(p.w, "%-8s %s is %s (%v)\n", e.Kind, name, e.Repo, elapsed)
+	case context.EventCopy, context.EventChecksum:
+		fmt.Fprintf(p.w, "%-8s %s %d files %s (%v)\n", e.Kind, name, e.Files, formatSize(e.Bytes), elapsed)
+	default:
+		fmt.Fprintf(p.w, "%-8s %s %s (%v)\n", e.Kind, name, formatSize(e.Bytes), elapsed)
+	}
+}
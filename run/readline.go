@@ -0,0 +1,360 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kardianos/govendor/context"
+
+	"github.com/Bowery/prompt"
+)
+
+// maxHistory is the number of lines kept in the shell history.
+const maxHistory = 500
+
+// errLineCanceled returns when the line is discarded with ctrl+C.
+var errLineCanceled = errors.New("line canceled")
+
+// lineReader reads shell lines with history and completion.
+type lineReader struct {
+	history     []string
+	historyFile string // Empty to not keep the history.
+
+	// complete returns the words that may complete the last word of head,
+	// the line before the cursor.
+	complete func(head string) []string
+}
+
+// historyDefault returns the default history file.
+func historyDefault() string {
+	dir := context.UserCacheDir()
+	if len(dir) == 0 {
+		return ""
+	}
+	return filepath.Join(dir, "shell_history")
+}
+
+// loadHistory reads the history file. A missing file is an empty history.
+func (lr *lineReader) loadHistory() error {
+	if len(lr.historyFile) == 0 {
+		return nil
+	}
+	b, err := ioutil.ReadFile(lr.historyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	lr.history = strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lr.history) == 1 && len(lr.history[0]) == 0 {
+		lr.history = nil
+	}
+	if len(lr.history) > maxHistory {
+		lr.history = lr.history[len(lr.history)-maxHistory:]
+		return lr.writeHistory()
+	}
+	return nil
+}
+
+func (lr *lineReader) writeHistory() error {
+	err := os.MkdirAll(filepath.Dir(lr.historyFile), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(lr.historyFile, []byte(strings.Join(lr.history, "\n")+"\n"), 0600)
+}
+
+var historySecret = regexp.MustCompile(`(://[^/@\s:]+):[^/@\s]+@`)
+
+// addHistory adds the line to the history and appends it to the history
+// file. Passwords in URLs are not kept.
+func (lr *lineReader) addHistory(line string) error {
+	line = historySecret.ReplaceAllString(strings.TrimSpace(line), "$1:xxxxx@")
+	if len(line) == 0 || (len(lr.history) > 0 && lr.history[len(lr.history)-1] == line) {
+		return nil
+	}
+	lr.history = append(lr.history, line)
+	if len(lr.historyFile) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(lr.historyFile), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(lr.historyFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(f, line)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// canEdit reports if the terminal supports line editing.
+func canEdit() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	switch os.Getenv("TERM") {
+	case "", "dumb", "cons25":
+		return false
+	}
+	_, _, err := prompt.TerminalSize(os.Stdout)
+	return err == nil
+}
+
+// readLine reads a line from the terminal. Without line editing support
+// the line is read as is.
+func (lr *lineReader) readLine(prefix string) (string, error) {
+	if !canEdit() {
+		return prompt.Basic(prefix, false)
+	}
+	term, err := prompt.NewTerminal()
+	if err != nil {
+		return "", err
+	}
+	defer term.Close()
+	return lr.edit(bufio.NewReader(term.In), term.Out, prefix)
+}
+
+// Keys read in raw mode.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyNewline   = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyReturn    = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// lineEdit is the line being edited.
+type lineEdit struct {
+	out    io.Writer
+	prefix string
+	line   []rune
+	pos    int
+}
+
+func (le *lineEdit) refresh() {
+	fmt.Fprintf(le.out, "\r%s%s\x1b[K\r", le.prefix, string(le.line))
+	if n := len([]rune(le.prefix)) + le.pos; n > 0 {
+		fmt.Fprintf(le.out, "\x1b[%dC", n)
+	}
+}
+
+func (le *lineEdit) set(s string) {
+	le.line = []rune(s)
+	le.pos = len(le.line)
+}
+
+func (le *lineEdit) insert(rs ...rune) {
+	line := make([]rune, 0, len(le.line)+len(rs))
+	line = append(line, le.line[:le.pos]...)
+	line = append(line, rs...)
+	le.line = append(line, le.line[le.pos:]...)
+	le.pos += len(rs)
+}
+
+func (le *lineEdit) delete(from, to int) {
+	le.line = append(le.line[:from], le.line[to:]...)
+	le.pos = from
+}
+
+// edit reads and edits a line from in, which must be in raw mode.
+func (lr *lineReader) edit(in *bufio.Reader, out io.Writer, prefix string) (string, error) {
+	le := &lineEdit{out: out, prefix: prefix}
+	histIdx := len(lr.history)
+	le.refresh()
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return string(le.line), err
+		}
+		switch r {
+		case keyReturn, keyNewline:
+			fmt.Fprint(out, "\r\n")
+			return string(le.line), nil
+		case keyCtrlC:
+			fmt.Fprint(out, "^C\r\n")
+			return "", errLineCanceled
+		case keyCtrlD:
+			if len(le.line) == 0 {
+				fmt.Fprint(out, "\r\n")
+				return "", io.EOF
+			}
+			if le.pos < len(le.line) {
+				le.delete(le.pos, le.pos+1)
+			}
+		case keyBackspace, keyDelete:
+			if le.pos > 0 {
+				le.delete(le.pos-1, le.pos)
+			}
+		case keyCtrlA:
+			le.pos = 0
+		case keyCtrlE:
+			le.pos = len(le.line)
+		case keyCtrlB:
+			if le.pos > 0 {
+				le.pos--
+			}
+		case keyCtrlF:
+			if le.pos < len(le.line) {
+				le.pos++
+			}
+		case keyCtrlK:
+			le.line = le.line[:le.pos]
+		case keyCtrlU:
+			le.delete(0, le.pos)
+		case keyCtrlL:
+			fmt.Fprint(out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			histIdx = lr.historyAt(le, histIdx-1, histIdx)
+		case keyCtrlN:
+			histIdx = lr.historyAt(le, histIdx+1, histIdx)
+		case keyTab:
+			lr.completeLine(le)
+		case keyEscape:
+			seq, err := readEscape(in)
+			if err != nil {
+				return string(le.line), err
+			}
+			switch seq {
+			case "[A", "OA":
+				histIdx = lr.historyAt(le, histIdx-1, histIdx)
+			case "[B", "OB":
+				histIdx = lr.historyAt(le, histIdx+1, histIdx)
+			case "[C", "OC":
+				if le.pos < len(le.line) {
+					le.pos++
+				}
+			case "[D", "OD":
+				if le.pos > 0 {
+					le.pos--
+				}
+			case "[H", "OH", "[1~", "[7~":
+				le.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				le.pos = len(le.line)
+			case "[3~":
+				if le.pos < len(le.line) {
+					le.delete(le.pos, le.pos+1)
+				}
+			}
+		default:
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			le.insert(r)
+		}
+		le.refresh()
+	}
+}
+
+// readEscape reads the rest of an escape sequence, such as "[A" or "[3~".
+func readEscape(in *bufio.Reader) (string, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	seq := []byte{b}
+	if b != '[' && b != 'O' {
+		return string(seq), nil
+	}
+	for {
+		b, err = in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, b)
+		if b < '0' || b > '9' {
+			return string(seq), nil
+		}
+	}
+}
+
+// historyAt sets the line to history item idx and returns the new index.
+// Past the last item is an empty line.
+func (lr *lineReader) historyAt(le *lineEdit, idx, current int) int {
+	switch {
+	case idx < 0:
+		return current
+	case idx >= len(lr.history):
+		le.set("")
+		return len(lr.history)
+	}
+	le.set(lr.history[idx])
+	return idx
+}
+
+// completeLine completes the word before the cursor. A single match is
+// completed in full, otherwise the common prefix of the matches is added
+// and the matches are listed.
+func (lr *lineReader) completeLine(le *lineEdit) {
+	if lr.complete == nil {
+		return
+	}
+	head := string(le.line[:le.pos])
+	word := head[strings.LastIndexAny(head, " \t")+1:]
+	list := lr.complete(head)
+	switch len(list) {
+	case 0:
+		return
+	case 1:
+		le.insert([]rune(strings.TrimPrefix(list[0], word) + " ")...)
+		return
+	}
+	common := list[0]
+	for _, item := range list[1:] {
+		for !strings.HasPrefix(item, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) > len(word) {
+		le.insert([]rune(strings.TrimPrefix(common, word))...)
+		return
+	}
+	fmt.Fprint(le.out, "\r\n")
+	for _, item := range list {
+		fmt.Fprintf(le.out, "%s\r\n", item)
+	}
+}
+
+// completeWords returns the words in list that start with word, sorted.
+func completeWords(word string, list []string) []string {
+	var found []string
+	for _, item := range list {
+		if strings.HasPrefix(item, word) {
+			found = append(found, item)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
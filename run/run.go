@@ -6,12 +6,15 @@
 package run
 
 import (
+	gocontext "context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"runtime/pprof"
+	"sync"
+	"time"
 
 	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
@@ -26,21 +29,40 @@ func (nw nullWriter) Write(p []byte) (n int, err error) {
 
 type runner struct {
 	ctx *context.Context
+
+	rootImport string            // Import path of a project outside GOPATH.
+	events     context.EventSink // Set on new contexts.
+
+	interrupt  gocontext.Context // Stops the operations of the context.
+	cmdTimeout time.Duration     // Time limit of each VCS command.
+
+	serveMu sync.Mutex // Serves one request at a time.
 }
 
 func (r *runner) NewContextWD(rt context.RootType) (*context.Context, error) {
-	if r.ctx != nil {
-		return r.ctx, nil
+	if r.ctx == nil {
+		var err error
+		r.ctx, err = context.NewContextWDImport(rt, r.rootImport)
+		if err != nil {
+			return r.ctx, err
+		}
 	}
-	var err error
-	r.ctx, err = context.NewContextWD(rt)
-	return r.ctx, err
+	// The shell keeps the context between commands, each with its own flags.
+	r.ctx.Events = r.events
+	r.ctx.Interrupt = r.interrupt
+	r.ctx.CommandTimeout = r.cmdTimeout
+	return r.ctx, nil
 }
 
 // Run is isoloated from main and os.Args to help with testing.
 // Shouldn't directly print to console, just write through w.
 func Run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.HelpMessage, error) {
-	r := &runner{}
+	return RunContext(gocontext.Background(), w, appArgs, ask)
+}
+
+// RunContext is Run that stops remote operations once c is done.
+func RunContext(c gocontext.Context, w io.Writer, appArgs []string, ask prompt.Prompt) (help.HelpMessage, error) {
+	r := &runner{interrupt: c}
 	return r.run(w, appArgs, ask)
 }
 func (r *runner) run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.HelpMessage, error) {
@@ -53,6 +75,10 @@ func (r *runner) run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.Hel
 	version := flags.Bool("version", false, "show govendor version")
 	cpuProfile := flags.String("cpuprofile", "", "write a CPU profile to `file` to help debug slow operations")
 	heapProfile := flags.String("heapprofile", "", "write a heap profile to `file` to help debug slow operations")
+	rootImport := flags.String("root-import", "", "import path of a project outside GOPATH")
+	showProgress := flags.Bool("progress", false, "show the progress of each step")
+	timeout := flags.Duration("timeout", 0, "time limit of all remote operations")
+	cmdTimeout := flags.Duration("cmd-timeout", 0, "time limit of each VCS command")
 
 	flags.SetOutput(nullWriter{})
 	err := flags.Parse(appArgs[1:])
@@ -75,6 +101,24 @@ func (r *runner) run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.Hel
 		defer done()
 	}
 
+	r.rootImport = *rootImport
+	r.cmdTimeout = *cmdTimeout
+	if r.interrupt == nil {
+		r.interrupt = gocontext.Background()
+	}
+	if *timeout > 0 {
+		parent := r.interrupt
+		var cancel gocontext.CancelFunc
+		r.interrupt, cancel = gocontext.WithTimeout(parent, *timeout)
+		defer func() {
+			cancel()
+			r.interrupt = parent
+		}()
+	}
+	r.events = nil
+	if *showProgress {
+		r.events = progress{w: w}
+	}
 	args := flags.Args()
 
 	cmd := args[0]
@@ -108,6 +152,20 @@ func (r *runner) run(w io.Writer, appArgs []string, ask prompt.Prompt) (help.Hel
 		return r.License(w, args[1:])
 	case "shell":
 		return r.Shell(w, args[1:])
+	case "serve":
+		return r.Serve(w, args[1:])
+	case "cache":
+		return r.Cache(w, args[1:])
+	case "audit":
+		return r.Audit(w, args[1:])
+	case "check":
+		return r.Check(w, args[1:])
+	case "patch":
+		return r.Patch(w, args[1:])
+	case "diff":
+		return r.Diff(w, args[1:])
+	case "upgrade-checksum":
+		return r.UpgradeChecksum(w, args[1:])
 	case "fmt", "build", "install", "clean", "test", "vet", "generate", "tool":
 		return r.GoCmd(cmd, args[1:])
 	default:
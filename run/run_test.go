@@ -6,11 +6,13 @@ package run
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kardianos/govendor/help"
 	"github.com/kardianos/govendor/internal/gt"
@@ -222,6 +224,7 @@ func TestTreeRootEmpty(t *testing.T) {
 	"package": [
 		{
 			"checksumSHA1": "LEK/OLgG216wx+DABfa4rfD6j14=",
+			"checksumSHA256": "6g1S+Pye2p3b8LCCwHbS5WweOO6hh0o0WKeDF/mKNFc=",
 			"path": "co2",
 			"revision": "",
 			"tree": true
@@ -237,3 +240,176 @@ func TestTreeRootEmpty(t *testing.T) {
  l  co1/pk1
 	`)
 }
+
+func TestStatusVerbose(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+		gt.File("b.go", "bytes"),
+	)
+	g.In("co1")
+	Vendor(g, "co1 init", "init", "")
+	err := ioutil.WriteFile(filepath.Join(g.Current(), relVendorFile), []byte(`{"ignore": "test", "manifest": "vendor.manifest.json"}`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Vendor(g, "co1 add ext", "add +ext", "")
+	Vendor(g, "co1 status", "status -v", "")
+
+	pkgDir := filepath.Join(g.Current(), "vendor", "co2", "pk1")
+	err = ioutil.WriteFile(filepath.Join(pkgDir, "a.go"), []byte("package pk1\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(filepath.Join(pkgDir, "b.go"), filepath.Join(pkgDir, "c.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	_, err = Run(output, []string{"testing", "status", "-v"}, &testPrompt{})
+	if err == nil {
+		t.Fatal("expected status to fail")
+	}
+	want := `The following packages are missing or modified locally:
+	co2/pk1
+		A c.go
+		D b.go
+		M a.go
+`
+	if output.String() != want {
+		t.Fatalf("Got\n%s", output.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	Vendor(g, "co1 init", "init", "")
+	err := ioutil.WriteFile(filepath.Join(g.Current(), relVendorFile), []byte(`{"manifest": "vendor.manifest.json"}`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Vendor(g, "co1 add ext", "add +ext", "")
+
+	output := &bytes.Buffer{}
+	_, err = Run(output, []string{"testing", "list", "-json", "-v"}, &testPrompt{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []jsonListItem
+	err = json.Unmarshal(output.Bytes(), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Got\n%s", output.String())
+	}
+	v, l := list[0], list[1]
+	if v.Path != "co2/pk1" || v.Local != "co1/vendor/co2/pk1" || v.Status.Location != "vendor" || v.Vendor == nil || len(v.Vendor.ChecksumSHA1) == 0 {
+		t.Errorf("unexpected vendor item %+v", v)
+	}
+	if len(v.ImportedBy) != 1 || v.ImportedBy[0] != "co1/pk1" {
+		t.Errorf("unexpected imported by %q", v.ImportedBy)
+	}
+	if l.Path != "co1/pk1" || l.Status.Location != "local" || l.Vendor != nil {
+		t.Errorf("unexpected local item %+v", l)
+	}
+
+	pkgDir := filepath.Join(g.Current(), "vendor", "co2", "pk1")
+	err = ioutil.WriteFile(filepath.Join(pkgDir, "a.go"), []byte("package pk1\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output.Reset()
+	_, err = Run(output, []string{"testing", "status", "-json"}, &testPrompt{})
+	if err == nil {
+		t.Fatal("expected status to fail")
+	}
+	var status []jsonStatusItem
+	err = json.Unmarshal(output.Bytes(), &status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || !status[0].Changed || !status[0].HasManifest || len(status[0].Modified) != 1 || status[0].Modified[0] != "a.go" {
+		t.Fatalf("Got\n%s", output.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1", "co3/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	Vendor(g, "co1 init", "init", "")
+	Vendor(g, "co1 add ext", "add +ext", "")
+	Vendor(g, "co1 check", "check -skip missing", `
+ok   unused
+ok   external
+ok   files
+ok   checksum
+ok   uncommitted
+ok   duplicate
+ok   revision
+`)
+
+	output := &bytes.Buffer{}
+	_, err := Run(output, []string{"testing", "check", "-format", "junit", "-checks", "missing,unused"}, &testPrompt{})
+	if err == nil {
+		t.Fatal("expected check to fail")
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="govendor check" tests="2" failures="1">
+	<testcase classname="govendor.check" name="missing">
+		<failure message="1 problem(s)">co3/pk1: missing, imported by co1/pk1</failure>
+	</testcase>
+	<testcase classname="govendor.check" name="unused"></testcase>
+</testsuite>
+`
+	if output.String() != want {
+		t.Fatalf("Got\n%s", output.String())
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	list := []struct {
+		In   string
+		Want time.Duration
+		Err  bool
+	}{
+		{In: "90d", Want: 90 * 24 * time.Hour},
+		{In: "2w", Want: 14 * 24 * time.Hour},
+		{In: "36h", Want: 36 * time.Hour},
+		{In: "xd", Err: true},
+		{In: "90", Err: true},
+	}
+	for _, item := range list {
+		got, err := parseAge(item.In)
+		if (err != nil) != item.Err {
+			t.Errorf("%q: unexpected error %v", item.In, err)
+			continue
+		}
+		if got != item.Want {
+			t.Errorf("%q: got %v, want %v", item.In, got, item.Want)
+		}
+	}
+}
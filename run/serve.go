@@ -0,0 +1,447 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kardianos/govendor/context"
+	"github.com/kardianos/govendor/help"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+	rpcRemoteFailures = -32001 // Some remote operations failed, the others were done.
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Missing for a notification.
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// serveArgs are the params of the list, license and sync methods.
+type serveArgs struct {
+	Args     []string `json:"args"`     // Status and package arguments, as on the command line.
+	Verbose  bool     `json:"verbose"`  // List: include importedBy and the external references of vendor packages.
+	Insecure bool     `json:"insecure"` // Sync: allow insecure network updates.
+}
+
+// serveModifyArgs are the params of the add, update, remove and fetch methods.
+type serveModifyArgs struct {
+	Args        []string `json:"args"`
+	Tree        bool     `json:"tree"`
+	Uncommitted bool     `json:"uncommitted"`
+	Short       bool     `json:"short"`
+	Long        bool     `json:"long"`
+	Insecure    bool     `json:"insecure"`
+	KeepGoing   bool     `json:"keepGoing"`
+	DryRun      bool     `json:"dryRun"`
+}
+
+type jsonOperation struct {
+	Type string `json:"type"` // copy, remove or fetch
+	Path string `json:"path"`
+	Src  string `json:"src"`
+	Dest string `json:"dest,omitempty"`
+	Done bool   `json:"done"`
+}
+
+type jsonModifyResult struct {
+	Operations []jsonOperation `json:"operations"`
+	Unresolved []string        `json:"unresolved,omitempty"`
+}
+
+type jsonRemoteFailure struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+type jsonEvent struct {
+	Kind     string `json:"kind"`
+	Done     bool   `json:"done"`
+	Package  string `json:"package,omitempty"`
+	Repo     string `json:"repo,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Elapsed  int64  `json:"elapsedMs,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Files    int    `json:"files,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// rpcConn writes the responses and notifications of one connection.
+type rpcConn struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (c *rpcConn) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Encode(v)
+}
+
+// Event sends each progress event as a "progress" notification.
+func (c *rpcConn) Event(e context.Event) {
+	je := jsonEvent{
+		Kind:     e.Kind.String(),
+		Done:     e.Done,
+		Package:  e.Package,
+		Repo:     e.Repo,
+		Path:     e.Path,
+		Revision: e.Revision,
+		Elapsed:  int64(e.Elapsed / time.Millisecond),
+		Bytes:    e.Bytes,
+		Files:    e.Files,
+	}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+	c.send(rpcNotification{JSONRPC: "2.0", Method: "progress", Params: je})
+}
+
+func (r *runner) Serve(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(nullWriter{})
+	socket := flags.String("socket", "", "unix socket to listen on instead of stdio")
+	err := flags.Parse(subCmdArgs)
+	if err != nil {
+		return help.MsgServe, err
+	}
+	if len(flags.Args()) != 0 {
+		return help.MsgServe, fmt.Errorf("unknown arguments %q", flags.Args())
+	}
+	if len(*socket) == 0 {
+		return help.MsgNone, r.serveConn(os.Stdin, os.Stdout)
+	}
+
+	// Remove a socket left by a server that did not stop cleanly.
+	if fi, err := os.Stat(*socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(*socket)
+	}
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	defer l.Close()
+	err = os.Chmod(*socket, 0600)
+	if err != nil {
+		return help.MsgNone, err
+	}
+	if r.interrupt != nil {
+		go func() {
+			<-r.interrupt.Done()
+			l.Close()
+		}()
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if r.interrupt != nil && r.interrupt.Err() != nil {
+				return help.MsgNone, nil
+			}
+			return help.MsgNone, err
+		}
+		go func() {
+			defer conn.Close()
+			r.serveConn(conn, conn)
+		}()
+	}
+}
+
+// serveConn reads requests from in until it ends and writes the responses
+// to out. Requests are served one at a time, even across connections, as
+// they share the context.
+func (r *runner) serveConn(in io.Reader, out io.Writer) error {
+	conn := &rpcConn{enc: json.NewEncoder(out)}
+	dec := json.NewDecoder(in)
+	for {
+		var req rpcRequest
+		err := dec.Decode(&req)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// The rest of the stream can't be read.
+			conn.send(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			return err
+		}
+		result, rerr := r.serveRequest(conn, req)
+		if len(req.ID) == 0 {
+			continue
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		switch {
+		case rerr != nil:
+			resp.Result = nil
+		case result == nil:
+			resp.Result = json.RawMessage("null")
+		}
+		err = conn.send(resp)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *runner) serveRequest(conn *rpcConn, req rpcRequest) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" || len(req.Method) == 0 {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+	}
+	r.serveMu.Lock()
+	defer r.serveMu.Unlock()
+
+	var mod context.Modify
+	var args serveArgs
+	var modArgs serveModifyArgs
+	params := interface{}(&args)
+	switch req.Method {
+	case "list", "status", "sync", "license":
+	case "add":
+		mod, params = context.Add, &modArgs
+	case "update":
+		mod, params = context.Update, &modArgs
+	case "remove":
+		mod, params = context.Remove, &modArgs
+	case "fetch":
+		mod, params = context.Fetch, &modArgs
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		err := json.Unmarshal(req.Params, params)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+	}
+
+	// Keep the context between requests, but with the files changed since.
+	if r.ctx != nil {
+		if _, err := r.ctx.Reload(); err != nil {
+			r.ctx = nil
+		}
+	}
+	r.events = conn
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "list":
+		result, err = r.serveList(args)
+	case "status":
+		result, err = r.serveStatus()
+	case "sync":
+		result, err = r.serveSync(args)
+	case "license":
+		result, err = r.serveLicense(args)
+	default:
+		result, err = r.serveModify(modArgs, mod)
+	}
+	r.events = nil
+	if r.ctx != nil {
+		r.ctx.Events = nil
+	}
+	if err != nil {
+		return result, serveError(err)
+	}
+	return result, nil
+}
+
+// serveError returns the error response of err. The remote failures are
+// listed in the error data.
+func serveError(err error) *rpcError {
+	if rem, is := err.(context.ErrRemoteFailures); is {
+		list := make([]jsonRemoteFailure, len(rem))
+		for i, fail := range rem {
+			list[i] = jsonRemoteFailure{Path: fail.Path, Message: fail.Msg}
+			if fail.Err != nil {
+				list[i].Error = fail.Err.Error()
+			}
+		}
+		return &rpcError{Code: rpcRemoteFailures, Message: err.Error(), Data: list}
+	}
+	return &rpcError{Code: rpcServerError, Message: err.Error()}
+}
+
+// serveFilter parses the status and package arguments as list does.
+func serveFilter(ctx *context.Context, args []string) (filter, error) {
+	cgp, err := currentGoPath(ctx)
+	if err != nil {
+		return filter{}, err
+	}
+	f, err := parseFilter(cgp, args)
+	if err != nil {
+		return f, err
+	}
+	if len(f.Import) == 0 {
+		insertListToAllNot(&f.Status, normal)
+	} else {
+		insertListToAllNot(&f.Status, all)
+	}
+	return f, nil
+}
+
+func (r *runner) serveList(args serveArgs) (interface{}, error) {
+	ctx, err := r.NewContextWD(context.RootVendorOrWD)
+	if err != nil {
+		return nil, err
+	}
+	f, err := serveFilter(ctx, args.Args)
+	if err != nil {
+		return nil, err
+	}
+	list, err := listItems(ctx, f, args.Verbose)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]jsonListItem, 0, len(list))
+	for _, item := range list {
+		out = append(out, newJSONListItem(ctx, item, args.Verbose))
+	}
+	return out, nil
+}
+
+func (r *runner) serveStatus() (interface{}, error) {
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return nil, err
+	}
+	outOfDate, err := ctx.VerifyVendorDetail()
+	if err != nil {
+		return nil, err
+	}
+	return newJSONStatusList(ctx, outOfDate), nil
+}
+
+func (r *runner) serveLicense(args serveArgs) (interface{}, error) {
+	ctx, err := r.NewContextWD(context.RootVendorOrWD)
+	if err != nil {
+		return nil, err
+	}
+	f, err := serveFilter(ctx, args.Args)
+	if err != nil {
+		return nil, err
+	}
+	list, err := findLicenses(ctx, f)
+	return []context.License(list), err
+}
+
+func (r *runner) serveSync(args serveArgs) (interface{}, error) {
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Logger = nil
+	ctx.Insecure = args.Insecure
+	ctx.Unresolved = nil
+	err = ctx.Sync(false)
+	return jsonModifyResult{
+		Operations: []jsonOperation{},
+		Unresolved: unresolvedList(ctx),
+	}, err
+}
+
+func (r *runner) serveModify(args serveModifyArgs, mod context.Modify) (interface{}, error) {
+	if len(args.Args) == 0 {
+		return nil, fmt.Errorf("missing package or status")
+	}
+	if args.Short && args.Long {
+		return nil, fmt.Errorf("cannot select both long and short path")
+	}
+	ctx, err := r.NewContextWD(context.RootVendor)
+	if err != nil {
+		return nil, err
+	}
+	// A failed or planned only change leaves the context changed, start
+	// again with a new context on the next request.
+	keep := false
+	defer func() {
+		if !keep {
+			r.ctx = nil
+		}
+	}()
+	ctx.Logger = nil
+	ctx.Insecure = args.Insecure
+	ctx.KeepGoing = args.KeepGoing
+	ctx.Unresolved = nil
+	f, err := serveFilter(ctx, args.Args)
+	if err != nil {
+		return nil, err
+	}
+	start := len(ctx.Operation)
+	err = planModify(ctx, f, mod, modifyOptions{
+		Short:       args.Short,
+		Long:        args.Long,
+		Tree:        args.Tree,
+		Uncommitted: args.Uncommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !args.DryRun {
+		err = applyModify(ctx)
+		if _, is := err.(context.ErrRemoteFailures); err == nil || is {
+			keep = true
+		}
+	}
+	result := jsonModifyResult{
+		Operations: []jsonOperation{},
+		Unresolved: unresolvedList(ctx),
+	}
+	for _, op := range ctx.Operation[start:] {
+		if op.State == context.OpIgnore {
+			continue
+		}
+		result.Operations = append(result.Operations, jsonOperation{
+			Type: op.Type.String(),
+			Path: op.Pkg.Path,
+			Src:  op.Src,
+			Dest: op.Dest,
+			Done: op.State == context.OpDone,
+		})
+	}
+	return result, err
+}
+
+func unresolvedList(ctx *context.Context) []string {
+	var list []string
+	for _, u := range ctx.Unresolved {
+		list = append(list, u.String())
+	}
+	return list
+}
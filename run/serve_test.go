@@ -0,0 +1,112 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kardianos/govendor/internal/gt"
+)
+
+func TestServe(t *testing.T) {
+	g := gt.New(t)
+	defer g.Clean()
+
+	g.Setup("co1/pk1",
+		gt.File("a.go", "co2/pk1"),
+	)
+	g.Setup("co2/pk1",
+		gt.File("a.go", "strings"),
+	)
+	g.In("co1")
+	Vendor(g, "co1 init", "init", "")
+
+	in := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "list", "params": {"args": ["+ext"]}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "add", "params": {"args": ["+ext"]}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "list", "params": {"args": ["+vendor"], "verbose": true}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "status"}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "remove", "params": {"args": []}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "vendor"}`,
+		`{"jsonrpc": "2.0", "method": "list"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "license", "params": {"args": ["+vendor"]}}`,
+	}, "\n")
+	out := &bytes.Buffer{}
+	r := &runner{}
+	g.Check(r.serveConn(strings.NewReader(in), out))
+
+	type response struct {
+		ID     int
+		Method string
+		Result json.RawMessage
+		Error  *rpcError
+	}
+	var list []response
+	notified := 0
+	dec := json.NewDecoder(out)
+	for {
+		var resp response
+		err := dec.Decode(&resp)
+		if err == io.EOF {
+			break
+		}
+		g.Check(err)
+		if resp.Method == "progress" {
+			notified++
+			continue
+		}
+		list = append(list, resp)
+	}
+	if notified == 0 {
+		t.Error("no progress notifications")
+	}
+	if len(list) != 7 {
+		t.Fatalf("got %d responses, want 7", len(list))
+	}
+	for i, resp := range list {
+		if resp.ID != i+1 {
+			t.Fatalf("response %d has id %d", i, resp.ID)
+		}
+	}
+
+	var items []jsonListItem
+	g.Check(json.Unmarshal(list[0].Result, &items))
+	if len(items) != 1 || items[0].Path != "co2/pk1" || items[0].Status.Location != "external" {
+		t.Errorf("list external: %s", list[0].Result)
+	}
+
+	var mod jsonModifyResult
+	g.Check(json.Unmarshal(list[1].Result, &mod))
+	if len(mod.Operations) != 1 || mod.Operations[0].Type != "copy" || mod.Operations[0].Path != "co2/pk1" || !mod.Operations[0].Done {
+		t.Errorf("add: %s", list[1].Result)
+	}
+
+	items = nil
+	g.Check(json.Unmarshal(list[2].Result, &items))
+	if len(items) != 1 || items[0].Path != "co2/pk1" || items[0].Vendor == nil || len(items[0].ImportedBy) != 1 {
+		t.Errorf("list vendor: %s", list[2].Result)
+	}
+
+	var status []jsonStatusItem
+	g.Check(json.Unmarshal(list[3].Result, &status))
+	if len(status) != 1 || status[0].Changed {
+		t.Errorf("status: %s", list[3].Result)
+	}
+
+	if list[4].Error == nil || list[4].Error.Code != rpcServerError {
+		t.Errorf("remove without args: %+v", list[4])
+	}
+	if list[5].Error == nil || list[5].Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method: %+v", list[5])
+	}
+
+	if list[6].Error != nil || !bytes.HasPrefix(list[6].Result, []byte("[")) {
+		t.Errorf("license: %+v", list[6])
+	}
+}
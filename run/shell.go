@@ -10,20 +10,36 @@ import (
 	"io"
 	"net"
 	"os"
+	"strings"
 
 	"net/http"
 	_ "net/http/pprof" // imported for side effect of registering handler
 
+	"github.com/kardianos/govendor/context"
 	"github.com/kardianos/govendor/help"
 
-	"github.com/Bowery/prompt"
 	"github.com/google/shlex"
 )
 
+// shellCommands are the sub-commands completed in the shell.
+var shellCommands = []string{
+	"add", "audit", "build", "cache", "check", "clean", "diff", "exit",
+	"fetch", "fmt", "generate", "get", "init", "install", "license", "list",
+	"migrate", "patch", "remove", "status", "sync", "test", "tool",
+	"update", "upgrade-checksum", "vet",
+}
+
+// statusNames are the status names completed after "+".
+var statusNames = []string{
+	"all", "excluded", "external", "local", "missing", "normal", "outside",
+	"program", "std", "unused", "vendor",
+}
+
 func (r *runner) Shell(w io.Writer, subCmdArgs []string) (help.HelpMessage, error) {
 	flags := flag.NewFlagSet("shell", flag.ContinueOnError)
 
 	pprofHandlerAddr := flags.String("pprof-handler", "", "if set, turns on an HTTP server that offers pprof handlers")
+	historyFile := flags.String("history", historyDefault(), "file to keep the shell history in")
 
 	flags.SetOutput(nullWriter{})
 	err := flags.Parse(subCmdArgs)
@@ -37,11 +53,31 @@ func (r *runner) Shell(w io.Writer, subCmdArgs []string) (help.HelpMessage, erro
 
 	out := os.Stdout
 
+	lr := &lineReader{
+		historyFile: *historyFile,
+		complete:    r.completeShell,
+	}
+	err = lr.loadHistory()
+	if err != nil {
+		fmt.Fprintf(out, "Failed to load history: %v\n", err)
+	}
+
 	for {
-		line, err := prompt.Basic("> ", false)
+		line, err := lr.readLine("> ")
+		if err == errLineCanceled {
+			continue
+		}
 		if err != nil {
 			break
 		}
+		err = lr.addHistory(line)
+		if err != nil {
+			fmt.Fprintf(out, "Failed to save history: %v\n", err)
+		}
+		err = r.reload(out)
+		if err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+		}
 		args, err := shlex.Split(line)
 		if err != nil {
 			fmt.Fprintf(out, "%v", err.Error())
@@ -67,11 +103,74 @@ func (r *runner) Shell(w io.Writer, subCmdArgs []string) (help.HelpMessage, erro
 		if len(msgText) > 0 {
 			fmt.Fprintf(out, "%s\tType \"exit\" to exit.\n", msgText)
 		}
+		if r.interrupt != nil && r.interrupt.Err() != nil {
+			// Interrupted while running the command.
+			return help.MsgNone, nil
+		}
 	}
 
 	return help.MsgNone, nil
 }
 
+// reload updates the shell context with the files changed since the last
+// command, such as after editing code or checking out another revision.
+// The unresolved references of the last command are cleared.
+func (r *runner) reload(w io.Writer) error {
+	if r.ctx == nil {
+		return nil
+	}
+	r.ctx.Unresolved = nil
+	changed, err := r.ctx.Reload()
+	if err != nil {
+		// Start again with a new context on the next command.
+		r.ctx = nil
+		return err
+	}
+	if len(changed) > 0 {
+		fmt.Fprintf(w, "Reloaded %d changed file(s) and folder(s).\n", len(changed))
+	}
+	return nil
+}
+
+// completeShell returns the completions of the last word of head: the
+// sub-commands for the first word, status names after "+" and otherwise
+// the import paths of the packages of the loaded context.
+func (r *runner) completeShell(head string) []string {
+	word := head[strings.LastIndexAny(head, " \t")+1:]
+	if len(strings.Fields(head[:len(head)-len(word)])) == 0 {
+		return completeWords(word, shellCommands)
+	}
+	if strings.HasPrefix(word, "+") {
+		// Complete the last status in a list such as "+vendor,^unused".
+		i := strings.LastIndexAny(word, "+,^") + 1
+		list := completeWords(word[i:], statusNames)
+		for j := range list {
+			list[j] = word[:i] + list[j]
+		}
+		return list
+	}
+	if r.ctx == nil || strings.HasPrefix(word, "-") {
+		return nil
+	}
+	seen := make(map[string]bool, len(r.ctx.Package))
+	var paths []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	for _, pkg := range r.ctx.Package {
+		if pkg.Status.Location != context.LocationStandard {
+			add(pkg.Path)
+		}
+	}
+	for _, vp := range r.ctx.VendorFile.Package {
+		add(vp.Path)
+	}
+	return completeWords(word, paths)
+}
+
 // tryEnableHTTPPprofHandler tries to provide an http/pprof handler on `addr`.
 // if it fails, it logs an error but does not otherwise do anything.
 func tryEnableHTTPPprofHandler(addr string) {
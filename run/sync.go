@@ -6,6 +6,7 @@ package run
 
 import (
 	"flag"
+	"fmt"
 	"io"
 
 	"github.com/kardianos/govendor/context"
@@ -17,6 +18,7 @@ func (r *runner) Sync(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 	insecure := flags.Bool("insecure", false, "allow insecure network updates")
 	dryrun := flags.Bool("n", false, "dry run, print what would be done")
 	verbose := flags.Bool("v", false, "verbose output")
+	retry := flags.Int("retry", context.DefaultRetry.Attempts, "attempts for each network operation")
 	flags.SetOutput(nullWriter{})
 	err := flags.Parse(subCmdArgs)
 	if err != nil {
@@ -27,8 +29,22 @@ func (r *runner) Sync(w io.Writer, subCmdArgs []string) (help.HelpMessage, error
 		return help.MsgSync, err
 	}
 	ctx.Insecure = *insecure
+	ctx.Retry.Attempts = *retry
 	if *dryrun || *verbose {
 		ctx.Logger = w
 	}
-	return help.MsgNone, ctx.Sync(*dryrun)
+	err = ctx.Sync(*dryrun)
+	printUnresolved(w, ctx)
+	return help.MsgNone, err
+}
+
+// printUnresolved lists the package references that were not copied.
+func printUnresolved(w io.Writer, ctx *context.Context) {
+	if len(ctx.Unresolved) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "The following references could not be resolved and were not copied:\n")
+	for _, u := range ctx.Unresolved {
+		fmt.Fprintf(w, "\t%s\n", u)
+	}
 }
@@ -27,6 +27,23 @@ type File struct {
 
 	Ignore string
 
+	// Prune is a space separated list of file and folder glob patterns
+	// that are not copied into any package. See Package.Prune.
+	Prune string
+
+	// Platforms is a space separated list of "goos/goarch" targets, such as
+	// "linux/amd64 linux/arm64". Files that can't build for any of them
+	// are not copied. Empty for all platforms.
+	Platforms string
+
+	// RequireChecksum is a space separated list of checksum algorithms
+	// each package must have, such as "sha1 sha256".
+	RequireChecksum string
+
+	// Manifest is the name of the file, relative to the vendor file folder,
+	// that records the hash of each vendored file. Empty if not used.
+	Manifest string
+
 	Package []*Package
 
 	// all preserves unknown values.
@@ -44,15 +61,46 @@ type Package struct {
 	Add bool
 
 	// See the vendor spec for definitions.
-	Origin       string
-	Path         string
-	Tree         bool
-	Revision     string
-	RevisionTime string
-	Version      string
-	VersionExact string
-	ChecksumSHA1 string
-	Comment      string
+	Origin         string
+	Path           string
+	Tree           bool
+	Revision       string
+	RevisionTime   string
+	Version        string
+	VersionExact   string
+	ChecksumSHA1   string
+	ChecksumSHA256 string
+	Comment        string
+
+	// Patches is a space separated list of patch file names applied in
+	// order after the package is copied. See context.PatchFolder.
+	Patches string
+
+	// Ignore is a space separated list of build tags ignored for this
+	// package in addition to the File.Ignore tags. A tag prefixed with "!"
+	// is not ignored for this package even if File.Ignore lists it.
+	Ignore string
+
+	// Prune is a space separated list of file and folder glob patterns
+	// that are not copied into this package, in addition to File.Prune.
+	// A pattern ending in "/" only matches folders. A pattern without any
+	// other "/" matches the file or folder name at any depth, otherwise
+	// it matches the path relative to the package folder.
+	Prune string
+
+	// PlatformExcluded is a space separated list of the files, relative to
+	// the package folder, that were not copied because they can't build
+	// for any of the File.Platforms.
+	PlatformExcluded string
+
+	// Assets is a space separated list of file and folder glob patterns,
+	// relative to the package folder, that are copied with the package.
+	Assets string
+
+	// ExtraFiles is a space separated list of the files, relative to the
+	// package folder, copied for cgo, go:embed or Assets references and
+	// included in the checksums. They may be outside the package folder.
+	ExtraFiles string
 }
 
 func (pkg *Package) PathOrigin() string {
@@ -82,18 +130,27 @@ func (list packageList) String() string {
 }
 
 var (
-	rootPathNames     = []string{"rootPath"}
-	packageNames      = []string{"package", "Package"}
-	ignoreNames       = []string{"ignore"}
-	originNames       = []string{"origin"}
-	pathNames         = []string{"path", "canonical", "Canonical", "vendor", "Vendor"}
-	treeNames         = []string{"tree"}
-	revisionNames     = []string{"revision", "Revision", "version", "Version"}
-	revisionTimeNames = []string{"revisionTime", "RevisionTime", "versionTime", "VersionTime"}
-	versionNames      = []string{"version"}
-	versionExactNames = []string{"versionExact"}
-	checksumSHA1Names = []string{"checksumSHA1"}
-	commentNames      = []string{"comment", "Comment"}
+	rootPathNames         = []string{"rootPath"}
+	packageNames          = []string{"package", "Package"}
+	ignoreNames           = []string{"ignore"}
+	pruneNames            = []string{"prune"}
+	platformsNames        = []string{"platforms"}
+	platformExcludedNames = []string{"platformExcluded"}
+	assetsNames           = []string{"assets"}
+	extraFilesNames       = []string{"extraFiles"}
+	requireChecksumNames  = []string{"requireChecksum"}
+	manifestNames         = []string{"manifest"}
+	originNames           = []string{"origin"}
+	pathNames             = []string{"path", "canonical", "Canonical", "vendor", "Vendor"}
+	treeNames             = []string{"tree"}
+	revisionNames         = []string{"revision", "Revision", "version", "Version"}
+	revisionTimeNames     = []string{"revisionTime", "RevisionTime", "versionTime", "VersionTime"}
+	versionNames          = []string{"version"}
+	versionExactNames     = []string{"versionExact"}
+	checksumSHA1Names     = []string{"checksumSHA1"}
+	checksumSHA256Names   = []string{"checksumSHA256"}
+	commentNames          = []string{"comment", "Comment"}
+	patchesNames          = []string{"patches"}
 )
 
 type vendorPackageSort []interface{}
@@ -195,6 +252,10 @@ func (vf *File) toFields() {
 	setField(&vf.RootPath, vf.all, rootPathNames)
 	setField(&vf.Comment, vf.all, commentNames)
 	setField(&vf.Ignore, vf.all, ignoreNames)
+	setField(&vf.Prune, vf.all, pruneNames)
+	setField(&vf.Platforms, vf.all, platformsNames)
+	setField(&vf.RequireChecksum, vf.all, requireChecksumNames)
+	setField(&vf.Manifest, vf.all, manifestNames)
 
 	rawPackageList := vf.getRawPackageList()
 
@@ -216,7 +277,14 @@ func (vf *File) toFields() {
 		setField(&pkg.Version, object, versionNames)
 		setField(&pkg.VersionExact, object, versionExactNames)
 		setField(&pkg.ChecksumSHA1, object, checksumSHA1Names)
+		setField(&pkg.ChecksumSHA256, object, checksumSHA256Names)
 		setField(&pkg.Comment, object, commentNames)
+		setField(&pkg.Patches, object, patchesNames)
+		setField(&pkg.Ignore, object, ignoreNames)
+		setField(&pkg.Prune, object, pruneNames)
+		setField(&pkg.PlatformExcluded, object, platformExcludedNames)
+		setField(&pkg.Assets, object, assetsNames)
+		setField(&pkg.ExtraFiles, object, extraFilesNames)
 	}
 }
 
@@ -227,6 +295,10 @@ func (vf *File) toAll() {
 	setObject(vf.RootPath, vf.all, rootPathNames, true)
 	setObject(vf.Comment, vf.all, commentNames, false)
 	setObject(vf.Ignore, vf.all, ignoreNames, false)
+	setObject(vf.Prune, vf.all, pruneNames, true)
+	setObject(vf.Platforms, vf.all, platformsNames, true)
+	setObject(vf.RequireChecksum, vf.all, requireChecksumNames, true)
+	setObject(vf.Manifest, vf.all, manifestNames, true)
 
 	rawPackageList := vf.getRawPackageList()
 
@@ -245,7 +317,14 @@ func (vf *File) toAll() {
 		setObject(pkg.Version, pkg.field, versionNames, true)
 		setObject(pkg.VersionExact, pkg.field, versionExactNames, true)
 		setObject(pkg.ChecksumSHA1, pkg.field, checksumSHA1Names, true)
+		setObject(pkg.ChecksumSHA256, pkg.field, checksumSHA256Names, true)
 		setObject(pkg.Comment, pkg.field, commentNames, true)
+		setObject(pkg.Patches, pkg.field, patchesNames, true)
+		setObject(pkg.Ignore, pkg.field, ignoreNames, true)
+		setObject(pkg.Prune, pkg.field, pruneNames, true)
+		setObject(pkg.PlatformExcluded, pkg.field, platformExcludedNames, true)
+		setObject(pkg.Assets, pkg.field, assetsNames, true)
+		setObject(pkg.ExtraFiles, pkg.field, extraFilesNames, true)
 	}
 
 	for i := len(vf.Package) - 1; i >= 0; i-- {
@@ -0,0 +1,71 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vendorfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Manifest records the hash of each file of the vendored packages so
+// changes to a package can be listed by file.
+type Manifest struct {
+	Package []*ManifestPackage `json:"package"`
+}
+
+// ManifestPackage is the file hash list of a single package.
+type ManifestPackage struct {
+	Path string `json:"path"`
+
+	// Files maps the slash separated file name, relative to the package
+	// folder, to the base64 encoded SHA-256 of the file content.
+	Files map[string]string `json:"files"`
+}
+
+// Find returns the package with the path or nil if not found.
+func (m *Manifest) Find(path string) *ManifestPackage {
+	for _, mp := range m.Package {
+		if mp.Path == path {
+			return mp
+		}
+	}
+	return nil
+}
+
+// Set adds or replaces the package with the same path.
+func (m *Manifest) Set(mp *ManifestPackage) {
+	for i, existing := range m.Package {
+		if existing.Path == mp.Path {
+			m.Package[i] = mp
+			return
+		}
+	}
+	m.Package = append(m.Package, mp)
+}
+
+// Marshal the manifest to the specified writer. Packages are sorted by path.
+func (m *Manifest) Marshal(w io.Writer) error {
+	sort.Slice(m.Package, func(i, j int) bool {
+		return m.Package[i].Path < m.Package[j].Path
+	})
+	jb, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	err = json.Indent(buf, jb, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, buf)
+	return err
+}
+
+// Unmarshal the manifest from the specified reader.
+func (m *Manifest) Unmarshal(r io.Reader) error {
+	return json.NewDecoder(r).Decode(m)
+}